@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/faultlog"
+	"github.com/FatProteins/master-thesis-code/util"
+	"github.com/spf13/pflag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// window is a period in which no client request completed successfully.
+type window struct {
+	start int64
+	end   int64
+}
+
+func (w window) overlap(start int64, end int64) time.Duration {
+	overlapStart := w.start
+	if start > overlapStart {
+		overlapStart = start
+	}
+	overlapEnd := w.end
+	if end < overlapEnd {
+		overlapEnd = end
+	}
+	if overlapEnd <= overlapStart {
+		return 0
+	}
+	return time.Duration(overlapEnd - overlapStart)
+}
+
+// unavailabilityWindows returns all periods longer than gap between two successful completions.
+// The requests must be sorted by timestampEnd.
+func unavailabilityWindows(requests []request, gap time.Duration) []window {
+	var windows []window
+	lastSuccess := int64(-1)
+	for _, r := range requests {
+		if !r.success {
+			continue
+		}
+		if lastSuccess >= 0 && r.timestampEnd-lastSuccess > int64(gap) {
+			windows = append(windows, window{start: lastSuccess, end: r.timestampEnd})
+		}
+		lastSuccess = r.timestampEnd
+	}
+	return windows
+}
+
+// faultImpact is the effect of a single fault on the clients within [start, end+horizon].
+type faultImpact struct {
+	event       faultlog.Event
+	unavailable time.Duration
+	// recovery is how long after the end of the fault the clients were still unavailable.
+	recovery time.Duration
+	errors   int
+	// burst is the largest number of errors within any burst window.
+	burst int
+}
+
+// correlate attributes the unavailability windows and errors within [start, end+horizon] of every fault to it.
+// The requests must be sorted by timestampEnd.
+func correlate(events []faultlog.Event, requests []request, windows []window, horizon time.Duration, burstWindow time.Duration) []faultImpact {
+	impacts := make([]faultImpact, 0, len(events))
+	for _, event := range events {
+		start := event.Start.UnixNano()
+		end := event.End.UnixNano()
+		horizonEnd := end + int64(horizon)
+		impact := faultImpact{event: event}
+
+		for _, w := range windows {
+			overlap := w.overlap(start, horizonEnd)
+			if overlap == 0 {
+				continue
+			}
+			impact.unavailable += overlap
+			// Windows that ended while the fault lasted are no recovery from it.
+			if w.end > end && time.Duration(w.end-end) > impact.recovery {
+				impact.recovery = time.Duration(w.end - end)
+			}
+		}
+
+		var failures []int64
+		first := sort.Search(len(requests), func(i int) bool { return requests[i].timestampEnd >= start })
+		for _, r := range requests[first:] {
+			if r.timestampEnd > horizonEnd {
+				break
+			}
+			if !r.success {
+				failures = append(failures, r.timestampEnd)
+			}
+		}
+		impact.errors = len(failures)
+		impact.burst = maxBurst(failures, burstWindow)
+
+		impacts = append(impacts, impact)
+	}
+	return impacts
+}
+
+// maxBurst returns the largest number of the sorted timestamps within any window of the given length.
+func maxBurst(timestamps []int64, window time.Duration) int {
+	burst := 0
+	first := 0
+	for last, timestamp := range timestamps {
+		for timestamp-timestamps[first] >= int64(window) {
+			first++
+		}
+		burst = util.Max(burst, last-first+1)
+	}
+	return burst
+}
+
+type faultTypeSummary struct {
+	action       string
+	count        int
+	unavailable  time.Duration
+	meanRecovery time.Duration
+	maxRecovery  time.Duration
+	errors       int
+	maxBurst     int
+}
+
+func summarizeFaultTypes(impacts []faultImpact) []faultTypeSummary {
+	summaries := make(map[string]*faultTypeSummary)
+	recoverySums := make(map[string]time.Duration)
+	for _, impact := range impacts {
+		s, ok := summaries[impact.event.Action]
+		if !ok {
+			s = &faultTypeSummary{action: impact.event.Action}
+			summaries[impact.event.Action] = s
+		}
+		s.count++
+		s.unavailable += impact.unavailable
+		s.errors += impact.errors
+		recoverySums[impact.event.Action] += impact.recovery
+		if impact.recovery > s.maxRecovery {
+			s.maxRecovery = impact.recovery
+		}
+		if impact.burst > s.maxBurst {
+			s.maxBurst = impact.burst
+		}
+	}
+
+	result := make([]faultTypeSummary, 0, len(summaries))
+	for action, s := range summaries {
+		s.meanRecovery = recoverySums[action] / time.Duration(s.count)
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].action < result[j].action })
+	return result
+}
+
+func writeFaultImpacts(path string, impacts []faultImpact) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	_ = writer.Write([]string{"action", "node", "timestampStart", "timestampEnd", "messageType", "unavailableNs", "recoveryNs", "errors", "maxErrorBurst"})
+	for _, impact := range impacts {
+		_ = writer.Write([]string{
+			impact.event.Action,
+			strconv.FormatUint(uint64(impact.event.Node), 10),
+			strconv.FormatInt(impact.event.Start.UnixNano(), 10),
+			strconv.FormatInt(impact.event.End.UnixNano(), 10),
+			impact.event.MessageType,
+			strconv.FormatInt(int64(impact.unavailable), 10),
+			strconv.FormatInt(int64(impact.recovery), 10),
+			strconv.Itoa(impact.errors),
+			strconv.Itoa(impact.burst),
+		})
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func runFaults(args []string) error {
+	flags := pflag.NewFlagSet("faults", pflag.ContinueOnError)
+	faultLogPaths := flags.StringSliceP("fault-log", "f", nil, "Fault logs written by the DAs (fault-log-path)")
+	historyPaths := flags.StringSliceP("history", "H", nil, "CSV files or directories of masternode runs recorded during the fault experiment")
+	outDir := flags.StringP("out", "o", "analysis", "Directory to write the fault impact CSV to")
+	gap := flags.Duration("gap", time.Second, "Minimum time without successful completion to count as unavailable")
+	horizon := flags.Duration("horizon", 10*time.Second, "Time after the end of a fault that is still attributed to it")
+	burstWindow := flags.Duration("burst-window", time.Second, "Window in which client errors count as one burst")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(*faultLogPaths) == 0 || len(*historyPaths) == 0 {
+		return fmt.Errorf("--fault-log and --history are required")
+	}
+
+	var events []faultlog.Event
+	for _, path := range *faultLogPaths {
+		fileEvents, err := faultlog.Read(path)
+		if err != nil {
+			return err
+		}
+		events = append(events, fileEvents...)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+
+	var requests []request
+	for _, path := range *historyPaths {
+		histories, err := readHistories(path)
+		if err != nil {
+			return err
+		}
+		for _, h := range histories {
+			requests = append(requests, h.requests...)
+		}
+	}
+	sort.Slice(requests, func(i, j int) bool { return requests[i].timestampEnd < requests[j].timestampEnd })
+
+	windows := unavailabilityWindows(requests, *gap)
+	logger.Info("Found %d unavailability windows longer than %s", len(windows), gap.String())
+	for _, w := range windows {
+		logger.Info("Unavailable from %s for %s", time.Unix(0, w.start).Format(time.RFC3339Nano), time.Duration(w.end-w.start).String())
+	}
+
+	impacts := correlate(events, requests, windows, *horizon, *burstWindow)
+	logger.Info("Action\tNode\tStart\tDuration\tUnavailable\tRecovery\tErrors\tMax error burst")
+	for _, impact := range impacts {
+		logger.Info("%s\t%d\t%s\t%s\t%s\t%s\t%d\t%d", impact.event.Action, impact.event.Node, impact.event.Start.Format(time.RFC3339Nano),
+			impact.event.End.Sub(impact.event.Start).String(), impact.unavailable.String(), impact.recovery.String(), impact.errors, impact.burst)
+	}
+
+	logger.Info("Action\tFaults\tUnavailable\tMean recovery\tMax recovery\tErrors\tMax error burst")
+	for _, s := range summarizeFaultTypes(impacts) {
+		logger.Info("%s\t%d\t%s\t%s\t%s\t%d\t%d", s.action, s.count, s.unavailable.String(), s.meanRecovery.String(), s.maxRecovery.String(), s.errors, s.maxBurst)
+	}
+
+	err = os.MkdirAll(*outDir, 0755)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(*outDir, "fault-impact.csv")
+	err = writeFaultImpacts(path, impacts)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Wrote fault impact to '%s'", path)
+	return nil
+}
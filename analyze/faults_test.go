@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/faultlog"
+)
+
+// at returns the timestamp of the second of the synthetic experiment.
+func at(seconds float64) int64 {
+	return int64(seconds * float64(time.Second))
+}
+
+func fault(action string, startSeconds float64, endSeconds float64) faultlog.Event {
+	return faultlog.Event{Action: action, Node: 1, Start: time.Unix(0, at(startSeconds)), End: time.Unix(0, at(endSeconds))}
+}
+
+func completedAt(seconds float64, success bool) request {
+	return request{key: "k", success: success, timestampStart: at(seconds) - at(0.01), timestampEnd: at(seconds)}
+}
+
+func TestCorrelate(t *testing.T) {
+	events := []faultlog.Event{fault("Halt", 10, 12), fault("Stop", 20, 21), fault("Halt", 30, 35)}
+	requests := []request{
+		completedAt(11, false), completedAt(11.1, true), completedAt(11.2, false), completedAt(11.4, false),
+		completedAt(13.5, false), completedAt(20.5, false), completedAt(32, false), completedAt(32.5, false),
+	}
+	// The second window ends before its fault does, so the fault needs no recovery.
+	windows := []window{{start: at(11), end: at(14)}, {start: at(31), end: at(33)}}
+
+	impacts := correlate(events, requests, windows, 5*time.Second, time.Second)
+	expected := []faultImpact{
+		{event: events[0], unavailable: 3 * time.Second, recovery: 2 * time.Second, errors: 4, burst: 3},
+		{event: events[1], errors: 1, burst: 1},
+		{event: events[2], unavailable: 2 * time.Second, errors: 2, burst: 2},
+	}
+	if !reflect.DeepEqual(impacts, expected) {
+		t.Errorf("got impacts %+v, want %+v", impacts, expected)
+	}
+
+	summaries := summarizeFaultTypes(impacts)
+	expectedSummaries := []faultTypeSummary{
+		{action: "Halt", count: 2, unavailable: 5 * time.Second, meanRecovery: time.Second, maxRecovery: 2 * time.Second, errors: 6, maxBurst: 3},
+		{action: "Stop", count: 1, errors: 1, maxBurst: 1},
+	}
+	if !reflect.DeepEqual(summaries, expectedSummaries) {
+		t.Errorf("got summaries %+v, want %+v", summaries, expectedSummaries)
+	}
+}
+
+func TestMaxBurst(t *testing.T) {
+	tests := []struct {
+		name       string
+		timestamps []int64
+		burst      int
+	}{
+		{name: "no errors", burst: 0},
+		{name: "single error", timestamps: []int64{at(1)}, burst: 1},
+		{name: "errors spread out", timestamps: []int64{at(0), at(0.5), at(1), at(1.5)}, burst: 2},
+		{name: "burst after single error", timestamps: []int64{at(0), at(5), at(5.1), at(5.2), at(5.9), at(6.5)}, burst: 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if burst := maxBurst(test.timestamps, time.Second); burst != test.burst {
+				t.Errorf("got a burst of %d errors, want %d", burst, test.burst)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// request is a single client operation as written by masternode.runStorage.
+type request struct {
+	key            string
+	success        bool
+	timestampStart int64
+	timestampEnd   int64
+}
+
+func (r request) latency() int64 {
+	return r.timestampEnd - r.timestampStart
+}
+
+// history contains all requests of one masternode run.
+type history struct {
+	name     string
+	clients  int
+	requests []request
+}
+
+var historyColumns = []string{"key", "success", "timestampStart", "timestampEnd"}
+
+var clientsPattern = regexp.MustCompile(`^([0-9]+)-`)
+
+func readHistory(path string) (*history, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read header of '%s': %w", path, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for idx, column := range header {
+		columns[column] = idx
+	}
+	for _, column := range historyColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("history '%s' is missing column '%s'", path, column)
+		}
+	}
+
+	result := &history{name: filepath.Base(path)}
+	if match := clientsPattern.FindStringSubmatch(result.name); match != nil {
+		result.clients, _ = strconv.Atoi(match[1])
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("could not read line %d of '%s': %w", line, path, err)
+		}
+		if len(record) < len(header) {
+			return nil, fmt.Errorf("line %d of '%s' has %d of %d columns", line, path, len(record), len(header))
+		}
+
+		success, err := strconv.ParseBool(record[columns["success"]])
+		if err != nil {
+			return nil, fmt.Errorf("invalid success value in line %d of '%s': %w", line, path, err)
+		}
+		timestampStart, err := strconv.ParseInt(record[columns["timestampStart"]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestampStart in line %d of '%s': %w", line, path, err)
+		}
+		timestampEnd, err := strconv.ParseInt(record[columns["timestampEnd"]], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestampEnd in line %d of '%s': %w", line, path, err)
+		}
+
+		result.requests = append(result.requests, request{
+			key:            record[columns["key"]],
+			success:        success,
+			timestampStart: timestampStart,
+			timestampEnd:   timestampEnd,
+		})
+	}
+
+	sort.Slice(result.requests, func(i, j int) bool {
+		return result.requests[i].timestampEnd < result.requests[j].timestampEnd
+	})
+	return result, nil
+}
+
+// readHistories reads a single history file or every csv file of a directory, ordered by their number of clients.
+func readHistories(path string) ([]*history, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		h, err := readHistory(path)
+		if err != nil {
+			return nil, err
+		}
+		return []*history{h}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.csv"))
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make([]*history, 0, len(files))
+	for _, file := range files {
+		h, err := readHistory(file)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, h)
+	}
+
+	// File names sort "10-" before "2-", so the client counts are compared as numbers.
+	sort.Slice(histories, func(i, j int) bool {
+		if histories[i].clients != histories[j].clients {
+			return histories[i].clients < histories[j].clients
+		}
+		return histories[i].name < histories[j].name
+	})
+	return histories, nil
+}
+
+// runPair is a baseline and an instrumented run with the same number of clients.
+type runPair struct {
+	baseline     *history
+	instrumented *history
+}
+
+// pairRuns pairs the runs by their number of clients. Both sides must have the same number of runs per client
+// count, runs of the same client count are paired in order of their names.
+func pairRuns(baseline []*history, instrumented []*history) ([]runPair, error) {
+	if len(baseline) != len(instrumented) {
+		return nil, fmt.Errorf("baseline has %d runs but instrumented has %d runs", len(baseline), len(instrumented))
+	}
+
+	pending := make(map[int][]*history)
+	for _, h := range instrumented {
+		pending[h.clients] = append(pending[h.clients], h)
+	}
+
+	pairs := make([]runPair, 0, len(baseline))
+	for _, h := range baseline {
+		candidates := pending[h.clients]
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("instrumented has no run with %d clients for baseline run '%s'", h.clients, h.name)
+		}
+		pairs = append(pairs, runPair{baseline: h, instrumented: candidates[0]})
+		pending[h.clients] = candidates[1:]
+	}
+	return pairs, nil
+}
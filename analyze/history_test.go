@@ -0,0 +1,119 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// writeHistory writes a masternode history with the rows below the header.
+func writeHistory(t *testing.T, dir string, name string, rows ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	content := strings.Join(append([]string{"key,success,timestampStart,timestampEnd"}, rows...), "\n") + "\n"
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadHistoriesOrdersByClients(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"10-run.csv", "2-run.csv", "5-run.csv"} {
+		writeHistory(t, dir, name, "k,true,0,1000")
+	}
+
+	histories, err := readHistories(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clients := make([]int, 0, len(histories))
+	for _, h := range histories {
+		clients = append(clients, h.clients)
+	}
+	if len(clients) != 3 || clients[0] != 2 || clients[1] != 5 || clients[2] != 10 {
+		t.Errorf("got runs with %v clients, want [2 5 10]", clients)
+	}
+}
+
+func TestReadHistoryRejectsShortRows(t *testing.T) {
+	path := writeHistory(t, t.TempDir(), "2-run.csv", "k,true,0,1000", "k,true,0")
+	_, err := readHistory(path)
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("got %v, want an error for line 3", err)
+	}
+}
+
+// runs returns empty histories with the names and the number of clients in the names.
+func runs(names ...string) []*history {
+	histories := make([]*history, 0, len(names))
+	for _, name := range names {
+		h := &history{name: name}
+		if match := clientsPattern.FindStringSubmatch(name); match != nil {
+			h.clients, _ = strconv.Atoi(match[1])
+		}
+		histories = append(histories, h)
+	}
+	return histories
+}
+
+func TestPairRuns(t *testing.T) {
+	tests := []struct {
+		name         string
+		baseline     []string
+		instrumented []string
+		pairs        [][2]string
+		err          bool
+	}{
+		{
+			name:         "pairs by clients",
+			baseline:     []string{"2-noop.csv", "5-noop.csv", "10-noop.csv"},
+			instrumented: []string{"10-pure.csv", "2-pure.csv", "5-pure.csv"},
+			pairs:        [][2]string{{"2-noop.csv", "2-pure.csv"}, {"5-noop.csv", "5-pure.csv"}, {"10-noop.csv", "10-pure.csv"}},
+		},
+		{
+			name:         "pairs runs with the same clients in order",
+			baseline:     []string{"2-a.csv", "2-b.csv"},
+			instrumented: []string{"2-c.csv", "2-d.csv"},
+			pairs:        [][2]string{{"2-a.csv", "2-c.csv"}, {"2-b.csv", "2-d.csv"}},
+		},
+		{
+			name:         "different number of runs",
+			baseline:     []string{"2-noop.csv", "5-noop.csv"},
+			instrumented: []string{"2-pure.csv"},
+			err:          true,
+		},
+		{
+			name:         "missing clients",
+			baseline:     []string{"2-noop.csv", "5-noop.csv"},
+			instrumented: []string{"2-pure.csv", "10-pure.csv"},
+			err:          true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pairs, err := pairRuns(runs(test.baseline...), runs(test.instrumented...))
+			if test.err {
+				if err == nil {
+					t.Fatalf("got pairs %v, want an error", pairs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pairs) != len(test.pairs) {
+				t.Fatalf("got %d pairs, want %d", len(pairs), len(test.pairs))
+			}
+			for i, pair := range pairs {
+				if pair.baseline.name != test.pairs[i][0] || pair.instrumented.name != test.pairs[i][1] {
+					t.Errorf("pair %d is %s and %s, want %s and %s", i, pair.baseline.name, pair.instrumented.name, test.pairs[i][0], test.pairs[i][1])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/spf13/pflag"
+	"os"
+	"strings"
+)
+
+var logger = daLogger.NewLogger("analyze")
+
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+var commands = map[string]command{
+	"benchmark": {"Compare baseline and DA-instrumented masternode runs", runBenchmark},
+	"faults":    {"Correlate DA fault logs with masternode runs", runFaults},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		logger.Error("Unknown command '%s'", os.Args[1])
+		usage()
+		os.Exit(1)
+	}
+
+	err := cmd.run(os.Args[2:])
+	if err != nil {
+		logger.ErrorErr(err, "Command '%s' failed", os.Args[1])
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	var builder strings.Builder
+	builder.WriteString("Usage: analyze <command> [flags]\n\nCommands:\n")
+	for name, cmd := range commands {
+		builder.WriteString(fmt.Sprintf("  %-12s %s\n", name, cmd.description))
+	}
+	fmt.Fprint(os.Stderr, builder.String())
+}
+
+func runBenchmark(args []string) error {
+	flags := pflag.NewFlagSet("benchmark", pflag.ContinueOnError)
+	baselinePath := flags.StringP("baseline", "b", "", "CSV file or directory of masternode runs without DA (e.g. 'noop-local-5')")
+	instrumentedPath := flags.StringP("instrumented", "i", "", "CSV file or directory of masternode runs with DA (e.g. 'pure-local-5')")
+	outDir := flags.StringP("out", "o", "analysis", "Directory to write the SVG plots and HTML report to")
+	skipSeconds := flags.Int("skip-seconds", 0, "Number of seconds to skip at the start of each run")
+	maxSeconds := flags.Int("max-seconds", 0, "Maximum number of seconds to analyze per run after skipping (0 for all)")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if len(*baselinePath) == 0 || len(*instrumentedPath) == 0 {
+		return fmt.Errorf("--baseline and --instrumented are required")
+	}
+
+	baseline, err := readHistories(*baselinePath)
+	if err != nil {
+		return err
+	}
+	instrumented, err := readHistories(*instrumentedPath)
+	if err != nil {
+		return err
+	}
+
+	pairs, err := pairRuns(baseline, instrumented)
+	if err != nil {
+		return err
+	}
+
+	comparisons := make([]comparison, 0, len(pairs))
+	for _, pair := range pairs {
+		o := overhead{
+			baseline:     summarize(pair.baseline, *skipSeconds, *maxSeconds),
+			instrumented: summarize(pair.instrumented, *skipSeconds, *maxSeconds),
+		}
+		c := compare(o)
+		for _, row := range c.Rows {
+			logger.Info(strings.Join(row, "\t"))
+		}
+		comparisons = append(comparisons, c)
+	}
+
+	err = writeReport(*outDir, "DA overhead", comparisons)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Wrote report to '%s'", *outDir)
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	plotWidth   = 800
+	plotHeight  = 400
+	plotMargin  = 60
+	plotXTicks  = 10
+	plotYTicks  = 5
+	plotPalette = "#1f77b4,#ff7f0e,#2ca02c,#d62728,#9467bd,#8c564b"
+)
+
+type series struct {
+	name string
+	xs   []float64
+	ys   []float64
+}
+
+func indexSeries(name string, values []float64) series {
+	xs := make([]float64, len(values))
+	for idx := range values {
+		xs[idx] = float64(idx)
+	}
+	return series{name: name, xs: xs, ys: values}
+}
+
+// lineChart renders the given series as a standalone SVG document.
+func lineChart(title string, xLabel string, yLabel string, data []series) string {
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	yMax := 0.0
+	for _, s := range data {
+		for idx := range s.xs {
+			xMin = math.Min(xMin, s.xs[idx])
+			xMax = math.Max(xMax, s.xs[idx])
+			yMax = math.Max(yMax, s.ys[idx])
+		}
+	}
+	if math.IsInf(xMin, 1) {
+		xMin, xMax = 0, 1
+	}
+	if xMax == xMin {
+		xMax = xMin + 1
+	}
+	if yMax == 0 {
+		yMax = 1
+	}
+
+	innerWidth := float64(plotWidth - 2*plotMargin)
+	innerHeight := float64(plotHeight - 2*plotMargin)
+	scaleX := func(x float64) float64 {
+		return plotMargin + (x-xMin)/(xMax-xMin)*innerWidth
+	}
+	scaleY := func(y float64) float64 {
+		return plotHeight - plotMargin - y/yMax*innerHeight
+	}
+
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`, plotWidth, plotHeight)
+	fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="white"/>`)
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" font-size="16">%s</text>`, plotWidth/2, plotMargin/2, escape(title))
+
+	for i := 0; i <= plotXTicks; i++ {
+		x := xMin + (xMax-xMin)*float64(i)/plotXTicks
+		fmt.Fprintf(&svg, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#eee"/>`, scaleX(x), plotMargin, scaleX(x), plotHeight-plotMargin)
+		fmt.Fprintf(&svg, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, scaleX(x), plotHeight-plotMargin+15, formatTick(x))
+	}
+	for i := 0; i <= plotYTicks; i++ {
+		y := yMax * float64(i) / plotYTicks
+		fmt.Fprintf(&svg, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#eee"/>`, plotMargin, scaleY(y), plotWidth-plotMargin, scaleY(y))
+		fmt.Fprintf(&svg, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, plotMargin-5, scaleY(y)+4, formatTick(y))
+	}
+
+	fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`, plotMargin, plotHeight-plotMargin, plotWidth-plotMargin, plotHeight-plotMargin)
+	fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`, plotMargin, plotMargin, plotMargin, plotHeight-plotMargin)
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle">%s</text>`, plotWidth/2, plotHeight-plotMargin/4, escape(xLabel))
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" text-anchor="middle" transform="rotate(-90 %d %d)">%s</text>`, plotMargin/4, plotHeight/2, plotMargin/4, plotHeight/2, escape(yLabel))
+
+	colors := strings.Split(plotPalette, ",")
+	for idx, s := range data {
+		color := colors[idx%len(colors)]
+		points := make([]string, len(s.xs))
+		for i := range s.xs {
+			points[i] = fmt.Sprintf("%.1f,%.1f", scaleX(s.xs[i]), scaleY(s.ys[i]))
+		}
+		fmt.Fprintf(&svg, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, color, strings.Join(points, " "))
+
+		legendY := plotMargin + 15*idx
+		fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="3"/>`, plotWidth-plotMargin-120, legendY, plotWidth-plotMargin-100, legendY, color)
+		fmt.Fprintf(&svg, `<text x="%d" y="%d">%s</text>`, plotWidth-plotMargin-95, legendY+4, escape(s.name))
+	}
+
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
+
+func formatTick(value float64) string {
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.2f", value)
+}
+
+func escape(text string) string {
+	replacer := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+	return replacer.Replace(text)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const cdfPoints = 200
+
+type comparison struct {
+	Title      string
+	Rows       [][]string
+	Throughput template.HTML
+	Latency    template.HTML
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td, th { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Comparisons}}
+<h2>{{.Title}}</h2>
+<table>
+{{range $idx, $row := .Rows}}<tr>{{range $row}}{{if eq $idx 0}}<th>{{.}}</th>{{else}}<td>{{.}}</td>{{end}}{{end}}</tr>
+{{end}}</table>
+{{.Throughput}}
+{{.Latency}}
+{{end}}
+</body>
+</html>
+`))
+
+func summaryRow(label string, s *summary) []string {
+	row := []string{label, s.name, fmt.Sprint(s.requests), fmt.Sprintf("%.2f%%", 100*s.errorRate()), fmt.Sprintf("%.1f", s.meanTput), formatLatency(s.meanLat)}
+	for idx := range percentiles {
+		if idx < len(s.latencies) {
+			row = append(row, formatLatency(s.latencies[idx]))
+		} else {
+			row = append(row, "-")
+		}
+	}
+	return append(row, formatLatency(s.maxLat))
+}
+
+func overheadRow(o overhead) []string {
+	row := []string{"Overhead", "", fmt.Sprintf("%+d", o.instrumented.requests-o.baseline.requests), fmt.Sprintf("%+.2f%%", 100*(o.instrumented.errorRate()-o.baseline.errorRate())),
+		formatChange(o.throughputChange()), formatChange(o.latencyChange())}
+	for idx := range percentiles {
+		row = append(row, formatChange(o.percentileChange(idx)))
+	}
+	return append(row, formatChange(relativeChange(float64(o.baseline.maxLat), float64(o.instrumented.maxLat))))
+}
+
+func tableHeader() []string {
+	header := []string{"Run", "File", "Requests", "Errors", "Throughput [req/s]", "Mean latency"}
+	for _, p := range percentiles {
+		header = append(header, fmt.Sprintf("p%g", p*100))
+	}
+	return append(header, "Max")
+}
+
+func formatLatency(latency time.Duration) string {
+	return fmt.Sprintf("%.3fms", float64(latency)/float64(time.Millisecond))
+}
+
+func formatChange(change float64) string {
+	return fmt.Sprintf("%+.2f%%", 100*change)
+}
+
+func compare(o overhead) comparison {
+	title := fmt.Sprintf("%d clients", o.baseline.clients)
+	if o.baseline.clients == 0 {
+		title = o.baseline.name
+	}
+
+	baselineXs, baselineYs := o.baseline.latencyCdf(cdfPoints)
+	instrumentedXs, instrumentedYs := o.instrumented.latencyCdf(cdfPoints)
+	return comparison{
+		Title: title,
+		Rows: [][]string{
+			tableHeader(),
+			summaryRow("Baseline", o.baseline),
+			summaryRow("Instrumented", o.instrumented),
+			overheadRow(o),
+		},
+		Throughput: template.HTML(lineChart("Throughput for "+title, "Seconds", "Throughput [req/s]", []series{
+			indexSeries("Baseline", o.baseline.throughput),
+			indexSeries("Instrumented", o.instrumented.throughput),
+		})),
+		Latency: template.HTML(lineChart("Latency CDF for "+title, "Latency [ms]", "Fraction of requests", []series{
+			{name: "Baseline", xs: baselineXs, ys: baselineYs},
+			{name: "Instrumented", xs: instrumentedXs, ys: instrumentedYs},
+		})),
+	}
+}
+
+// writeReport writes an SVG file per plot and an HTML report containing all comparisons to outDir.
+func writeReport(outDir string, title string, comparisons []comparison) error {
+	err := os.MkdirAll(outDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range comparisons {
+		name := strings.ReplaceAll(c.Title, " ", "-")
+		err = os.WriteFile(filepath.Join(outDir, "throughput-"+name+".svg"), []byte(c.Throughput), 0644)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filepath.Join(outDir, "latency-"+name+".svg"), []byte(c.Latency), 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Create(filepath.Join(outDir, "report.html"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return reportTemplate.Execute(file, struct {
+		Title       string
+		Comparisons []comparison
+	}{title, comparisons})
+}
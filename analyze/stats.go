@@ -0,0 +1,135 @@
+package main
+
+import (
+	"gonum.org/v1/gonum/stat"
+	"sort"
+	"time"
+)
+
+var percentiles = []float64{0.5, 0.9, 0.95, 0.99}
+
+type summary struct {
+	name       string
+	clients    int
+	requests   int
+	errors     int
+	duration   time.Duration
+	throughput []float64
+	meanTput   float64
+	meanLat    time.Duration
+	maxLat     time.Duration
+	latencies  []time.Duration
+	sorted     []float64
+}
+
+func (s *summary) errorRate() float64 {
+	if s.requests == 0 {
+		return 0
+	}
+	return float64(s.errors) / float64(s.requests)
+}
+
+// summarize computes the throughput per second and latency statistics of a history.
+// The first skipSeconds seconds are dropped and at most maxSeconds seconds are kept if maxSeconds > 0.
+func summarize(h *history, skipSeconds int, maxSeconds int) *summary {
+	s := &summary{name: h.name, clients: h.clients}
+	if len(h.requests) == 0 {
+		return s
+	}
+
+	origin := h.requests[0].timestampEnd
+	for _, r := range h.requests {
+		if r.timestampStart < origin {
+			origin = r.timestampStart
+		}
+	}
+
+	var latencies []float64
+	var counts []float64
+	for _, r := range h.requests {
+		second := int((r.timestampEnd - origin) / int64(time.Second))
+		if second < skipSeconds || (maxSeconds > 0 && second >= skipSeconds+maxSeconds) {
+			continue
+		}
+
+		s.requests++
+		if !r.success {
+			s.errors++
+			continue
+		}
+
+		bucket := second - skipSeconds
+		for len(counts) <= bucket {
+			counts = append(counts, 0)
+		}
+		counts[bucket]++
+		latencies = append(latencies, float64(r.latency()))
+	}
+
+	s.throughput = counts
+	s.duration = time.Duration(len(counts)) * time.Second
+	if len(counts) > 0 {
+		s.meanTput = stat.Mean(counts, nil)
+	}
+	if len(latencies) == 0 {
+		return s
+	}
+
+	sort.Float64s(latencies)
+	s.sorted = latencies
+	s.meanLat = time.Duration(stat.Mean(latencies, nil))
+	s.maxLat = time.Duration(latencies[len(latencies)-1])
+	s.latencies = make([]time.Duration, len(percentiles))
+	for idx, p := range percentiles {
+		s.latencies[idx] = time.Duration(stat.Quantile(p, stat.Empirical, latencies, nil))
+	}
+
+	return s
+}
+
+// latencyCdf returns at most points samples of the empirical latency distribution in milliseconds.
+func (s *summary) latencyCdf(points int) ([]float64, []float64) {
+	if len(s.sorted) == 0 {
+		return nil, nil
+	}
+	if points > len(s.sorted) {
+		points = len(s.sorted)
+	}
+
+	xs := make([]float64, points)
+	ys := make([]float64, points)
+	for i := 0; i < points; i++ {
+		idx := (i + 1) * len(s.sorted) / points
+		xs[i] = s.sorted[idx-1] / float64(time.Millisecond)
+		ys[i] = float64(idx) / float64(len(s.sorted))
+	}
+	return xs, ys
+}
+
+// overhead describes the relative cost of the instrumented run compared to the baseline run.
+type overhead struct {
+	baseline     *summary
+	instrumented *summary
+}
+
+func (o overhead) throughputChange() float64 {
+	return relativeChange(o.baseline.meanTput, o.instrumented.meanTput)
+}
+
+func (o overhead) latencyChange() float64 {
+	return relativeChange(float64(o.baseline.meanLat), float64(o.instrumented.meanLat))
+}
+
+func (o overhead) percentileChange(idx int) float64 {
+	if idx >= len(o.baseline.latencies) || idx >= len(o.instrumented.latencies) {
+		return 0
+	}
+	return relativeChange(float64(o.baseline.latencies[idx]), float64(o.instrumented.latencies[idx]))
+}
+
+func relativeChange(baseline float64, value float64) float64 {
+	if baseline == 0 {
+		return 0
+	}
+	return (value - baseline) / baseline
+}
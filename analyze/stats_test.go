@@ -0,0 +1,93 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// requestAt returns a request that started at startMs and took latencyMs milliseconds.
+func requestAt(startMs int64, latencyMs int64, success bool) request {
+	start := startMs * int64(time.Millisecond)
+	return request{key: "k", success: success, timestampStart: start, timestampEnd: start + latencyMs*int64(time.Millisecond)}
+}
+
+func TestSummarize(t *testing.T) {
+	tenRequests := make([]request, 0, 10)
+	for latency := int64(1); latency <= 10; latency++ {
+		tenRequests = append(tenRequests, requestAt(10*latency, latency, true))
+	}
+
+	tests := []struct {
+		name        string
+		requests    []request
+		skipSeconds int
+		maxSeconds  int
+		expected    summary
+	}{
+		{
+			name:     "no requests",
+			expected: summary{},
+		},
+		{
+			name:     "percentiles",
+			requests: tenRequests,
+			expected: summary{
+				requests:   10,
+				throughput: []float64{10},
+				meanTput:   10,
+				meanLat:    5500 * time.Microsecond,
+				maxLat:     10 * time.Millisecond,
+				latencies:  []time.Duration{5 * time.Millisecond, 9 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond},
+			},
+		},
+		{
+			name:     "errors count as requests but not as throughput",
+			requests: []request{requestAt(0, 2, true), requestAt(100, 4, true), requestAt(200, 6, false), requestAt(1000, 6, true)},
+			expected: summary{
+				requests:   4,
+				errors:     1,
+				throughput: []float64{2, 1},
+				meanTput:   1.5,
+				meanLat:    4 * time.Millisecond,
+				maxLat:     6 * time.Millisecond,
+				latencies:  []time.Duration{4 * time.Millisecond, 6 * time.Millisecond, 6 * time.Millisecond, 6 * time.Millisecond},
+			},
+		},
+		{
+			name:        "skip and max seconds",
+			requests:    []request{requestAt(0, 1, true), requestAt(1000, 2, true), requestAt(2000, 3, true), requestAt(2500, 3, true), requestAt(3000, 4, true)},
+			skipSeconds: 1,
+			maxSeconds:  2,
+			expected: summary{
+				requests:   3,
+				throughput: []float64{1, 2},
+				meanTput:   1.5,
+				meanLat:    2666666 * time.Nanosecond,
+				maxLat:     3 * time.Millisecond,
+				latencies:  []time.Duration{3 * time.Millisecond, 3 * time.Millisecond, 3 * time.Millisecond, 3 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := summarize(&history{requests: test.requests}, test.skipSeconds, test.maxSeconds)
+			if s.requests != test.expected.requests || s.errors != test.expected.errors {
+				t.Errorf("got %d requests and %d errors, want %d and %d", s.requests, s.errors, test.expected.requests, test.expected.errors)
+			}
+			if len(s.throughput) != len(test.expected.throughput) || (len(s.throughput) > 0 && !reflect.DeepEqual(s.throughput, test.expected.throughput)) {
+				t.Errorf("got throughput %v, want %v", s.throughput, test.expected.throughput)
+			}
+			if s.meanTput != test.expected.meanTput {
+				t.Errorf("got mean throughput %v, want %v", s.meanTput, test.expected.meanTput)
+			}
+			if s.meanLat != test.expected.meanLat || s.maxLat != test.expected.maxLat {
+				t.Errorf("got mean latency %s and max %s, want %s and %s", s.meanLat, s.maxLat, test.expected.meanLat, test.expected.maxLat)
+			}
+			if !reflect.DeepEqual(s.latencies, test.expected.latencies) {
+				t.Errorf("got percentiles %v, want %v", s.latencies, test.expected.latencies)
+			}
+		})
+	}
+}
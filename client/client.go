@@ -0,0 +1,381 @@
+// Package client instruments a consensus implementation written in Go. Nodes report their consensus events to the
+// DA and block until the DA has performed its action, e.g. until a halt is over.
+//
+//	daClient, err := client.NewClient(client.Config{Address: "/volume/to-da.sock", NodeId: 1, ProtocolName: "raft"})
+//	...
+//	daClient.ReportVoteReceived(voterId, candidateId, granted)
+package client
+
+import (
+	"errors"
+	"fmt"
+	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/util"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"sync"
+	"time"
+)
+
+var logger = daLogger.NewLogger("client")
+
+const (
+	TransportUnix       = "unix"
+	TransportUnixPacket = "unixpacket"
+	TransportTcp        = "tcp"
+	TransportGrpc       = "grpc"
+)
+
+const (
+	// DefaultTimeout is longer than the halt durations used in the experiments.
+	DefaultTimeout = 30 * time.Second
+
+	minReconnectBackoff = 100 * time.Millisecond
+	maxReconnectBackoff = 5 * time.Second
+)
+
+// ErrFailedOpen is returned if the DA did not respond, because it is not connected or did not respond in time.
+// The node should carry on as if the DA chose the noop action.
+var ErrFailedOpen = errors.New("no DA response, failing open")
+
+// ErrRejected is returned together with ErrFailedOpen once the DA rejected the node, e.g. because of an
+// incompatible protocol version. The client does not reconnect then.
+var ErrRejected = errors.New("rejected by DA")
+
+// ErrConnectionLost is returned together with ErrFailedOpen if the connection was lost while waiting for the
+// response. The DA resets the connection when it stops the node.
+var ErrConnectionLost = errors.New("connection to DA lost")
+
+type Config struct {
+	// Transport is the transport the DA is configured with, 'unix' by default.
+	Transport string
+	// Address is the socket path for unix transports and host:port for tcp and grpc.
+	Address      string
+	NodeId       uint32
+	ProtocolName string
+	// Timeout is the longest a report blocks for the DA response, DefaultTimeout if zero.
+	Timeout time.Duration
+	// SupportedActions are the verdicts the node applies, the DA performs noop instead of other actions.
+	// If empty, the node supports all actions.
+	SupportedActions []protocol.ActionType
+	// HeartbeatInterval is the interval the client sends heartbeats in, heartbeats are disabled if zero. It must
+	// be well below the heartbeat timeout of the DA.
+	HeartbeatInterval time.Duration
+}
+
+// Client is the connection of a node to its DA. It is safe for concurrent use, reports of concurrent goroutines are
+// handled by the DA in the order they were sent. If the connection is lost, the client reconnects in the background
+// while reports fail open.
+type Client struct {
+	config     Config
+	mutex      sync.Mutex
+	conn       conn
+	pending    []chan *protocol.Message
+	connecting bool
+	closed     bool
+	rejected   error
+}
+
+// NewClient connects to the DA. If the DA is not reachable yet, the client keeps connecting in the background.
+func NewClient(config Config) (*Client, error) {
+	if len(config.Transport) == 0 {
+		config.Transport = TransportUnix
+	}
+	switch config.Transport {
+	case TransportUnix, TransportUnixPacket, TransportTcp, TransportGrpc:
+	default:
+		return nil, fmt.Errorf("unsupported transport '%s'", config.Transport)
+	}
+	if len(config.Address) == 0 {
+		return nil, errors.New("DA address is empty")
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	client := &Client{config: config}
+	err := client.connect()
+	if err != nil {
+		logger.ErrorErr(err, "Could not connect to DA at '%s', connecting in background", config.Address)
+		client.mutex.Lock()
+		client.reconnect()
+		client.mutex.Unlock()
+	}
+	if config.HeartbeatInterval > 0 {
+		go client.heartbeatLoop()
+	}
+
+	return client, nil
+}
+
+func (client *Client) ReportVoteRequestReceived(requestingNodeId uint32, receivingNodeId uint32, term uint64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_VOTE_REQUEST_RECEIVED, &protocol.VoteRequestReceived{RequestingNodeId: requestingNodeId, ReceivingNodeId: receivingNodeId, Term: term})
+}
+
+func (client *Client) ReportVoteReceived(votingNodeId uint32, votedNodeId uint32, voteGranted bool) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_VOTE_RECEIVED, &protocol.VoteReceived{VotingNodeId: votingNodeId, VotedNodeId: votedNodeId, VoteGranted: voteGranted})
+}
+
+func (client *Client) ReportLogEntryReplicated(leaderId uint32, receivingNodeId uint32, logEntryNumber int64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_LOG_ENTRY_REPLICATED, &protocol.LogEntryReplicated{LeaderId: leaderId, ReceivingNodeId: receivingNodeId, LogEntryNumber: logEntryNumber})
+}
+
+func (client *Client) ReportLogEntryCommitted(leaderId uint32, receivingNodeId uint32, logEntryNumber int64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_LOG_ENTRY_COMMITTED, &protocol.LogEntryCommitted{LeaderId: leaderId, ReceivingNodeId: receivingNodeId, LogEntryNumber: logEntryNumber})
+}
+
+func (client *Client) ReportLeaderSuspected(leaderId uint32, suspectingNodeId uint32) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_LEADER_SUSPECTED, &protocol.LeaderSuspected{LeaderId: leaderId, SuspectingNodeId: suspectingNodeId})
+}
+
+func (client *Client) ReportFollowerSuspected(leaderId uint32, followerId uint32) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_FOLLOWER_SUSPECTED, &protocol.FollowerSuspected{LeaderId: leaderId, FollowerId: followerId})
+}
+
+func (client *Client) ReportPrePrepareReceived(leaderId uint32, receivingNodeId uint32, view uint64, sequenceNumber uint64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_PRE_PREPARE_RECEIVED, &protocol.PrePrepareReceived{LeaderId: leaderId, ReceivingNodeId: receivingNodeId, View: view, SequenceNumber: sequenceNumber})
+}
+
+func (client *Client) ReportPrepareReceived(sendingNodeId uint32, receivingNodeId uint32, view uint64, sequenceNumber uint64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_PREPARE_RECEIVED, &protocol.PrepareReceived{SendingNodeId: sendingNodeId, ReceivingNodeId: receivingNodeId, View: view, SequenceNumber: sequenceNumber})
+}
+
+func (client *Client) ReportCommitReceived(sendingNodeId uint32, receivingNodeId uint32, view uint64, sequenceNumber uint64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_COMMIT_RECEIVED, &protocol.CommitReceived{SendingNodeId: sendingNodeId, ReceivingNodeId: receivingNodeId, View: view, SequenceNumber: sequenceNumber})
+}
+
+func (client *Client) ReportViewChangeStarted(nodeId uint32, suspectedLeaderId uint32, newView uint64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_VIEW_CHANGE_STARTED, &protocol.ViewChangeStarted{NodeId: nodeId, SuspectedLeaderId: suspectedLeaderId, NewView: newView})
+}
+
+func (client *Client) ReportCheckpointReached(nodeId uint32, sequenceNumber uint64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_CHECKPOINT_REACHED, &protocol.CheckpointReached{NodeId: nodeId, SequenceNumber: sequenceNumber})
+}
+
+func (client *Client) ReportStateTransferRequested(requestingNodeId uint32, receivingNodeId uint32, lastSequenceNumber uint64) (protocol.ActionType, error) {
+	return client.Report(protocol.MessageType_STATE_TRANSFER_REQUESTED, &protocol.StateTransferRequested{RequestingNodeId: requestingNodeId, ReceivingNodeId: receivingNodeId, LastSequenceNumber: lastSequenceNumber})
+}
+
+// Report sends an event to the DA and blocks until the DA responds with the action it performed. The node requests
+// no fault, see ReportAction. If the DA does not respond, Report returns the noop action and an error wrapping
+// ErrFailedOpen.
+func (client *Client) Report(messageType protocol.MessageType, event proto.Message) (protocol.ActionType, error) {
+	return client.ReportAction(messageType, event, protocol.ActionType_NOOP_ACTION_TYPE)
+}
+
+// ReportAction is Report, but requests an action from the DA. The DA performs the requested action unless the node
+// does not support it or the DA is configured to decide otherwise, e.g. in step mode or while replaying a trace.
+func (client *Client) ReportAction(messageType protocol.MessageType, event proto.Message, requested protocol.ActionType) (protocol.ActionType, error) {
+	messageObject, err := anypb.New(event)
+	if err != nil {
+		return protocol.ActionType_NOOP_ACTION_TYPE, err
+	}
+
+	respChan, err := client.send(&protocol.Message{MessageType: messageType, MessageObject: messageObject, ActionType: requested})
+	if err != nil {
+		return protocol.ActionType_NOOP_ACTION_TYPE, fmt.Errorf("%w: %w", ErrFailedOpen, err)
+	}
+
+	timer := time.NewTimer(client.config.Timeout)
+	defer timer.Stop()
+	select {
+	case response, ok := <-respChan:
+		if !ok {
+			return protocol.ActionType_NOOP_ACTION_TYPE, fmt.Errorf("%w: %w", ErrFailedOpen, ErrConnectionLost)
+		}
+		return response.ActionType, nil
+	case <-timer.C:
+		return protocol.ActionType_NOOP_ACTION_TYPE, fmt.Errorf("%w: no response within %s", ErrFailedOpen, client.config.Timeout.String())
+	}
+}
+
+// send writes the message and queues a channel for its response. The DA responds in order, so the responses are
+// matched to the queued channels in order, also those of reports that already timed out.
+func (client *Client) send(message *protocol.Message) (<-chan *protocol.Message, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.closed {
+		return nil, errors.New("client is closed")
+	}
+	if client.rejected != nil {
+		return nil, client.rejected
+	}
+	if client.conn == nil {
+		return nil, errors.New("not connected to DA")
+	}
+
+	err := client.conn.send(message)
+	if err != nil {
+		client.disconnect(client.conn, err)
+		return nil, err
+	}
+
+	respChan := make(chan *protocol.Message, 1)
+	client.pending = append(client.pending, respChan)
+	return respChan, nil
+}
+
+// heartbeatLoop sends heartbeats until the client is closed. The DA answers heartbeats at once with a noop, the
+// response is queued like any other and discarded. Heartbeats are skipped while disconnected.
+func (client *Client) heartbeatLoop() {
+	ticker := time.NewTicker(client.config.HeartbeatInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		client.mutex.Lock()
+		stopped := client.closed || client.rejected != nil
+		client.mutex.Unlock()
+		if stopped {
+			return
+		}
+		_, _ = client.send(&protocol.Message{MessageType: protocol.MessageType_HEARTBEAT})
+	}
+}
+
+// connect dials the DA and sends the handshake. Its acknowledgement is queued like any other response.
+func (client *Client) connect() error {
+	conn, err := dial(client.config.Transport, client.config.Address)
+	if err != nil {
+		return err
+	}
+
+	messageObject, err := anypb.New(&protocol.Handshake{
+		NodeId:           client.config.NodeId,
+		ProtocolName:     client.config.ProtocolName,
+		Version:          protocol.Version,
+		SupportedActions: client.config.SupportedActions,
+	})
+	if err != nil {
+		_ = conn.close()
+		return err
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if client.closed {
+		_ = conn.close()
+		return errors.New("client is closed")
+	}
+
+	err = conn.send(&protocol.Message{MessageType: protocol.MessageType_HANDSHAKE, MessageObject: messageObject})
+	if err != nil {
+		_ = conn.close()
+		return err
+	}
+
+	client.conn = conn
+	client.connecting = false
+	client.pending = append(client.pending, make(chan *protocol.Message, 1))
+	go client.receiveLoop(conn)
+	logger.Info("Connected to DA at '%s'", client.config.Address)
+	return nil
+}
+
+// checkHandshakeAck stops reconnecting if the DA rejected the node. DAs without versioning acknowledge with an
+// empty response. Must be called with the mutex held.
+func (client *Client) checkHandshakeAck(response *protocol.Message) {
+	if response.MessageObject == nil || !response.MessageObject.MessageIs(&protocol.HandshakeAck{}) {
+		return
+	}
+
+	ack := protocol.HandshakeAck{}
+	err := response.MessageObject.UnmarshalTo(&ack)
+	if err != nil || ack.Accepted {
+		return
+	}
+
+	logger.Error("DA at '%s' rejected the node: %s", client.config.Address, ack.Reason)
+	client.rejected = fmt.Errorf("%w: %s", ErrRejected, ack.Reason)
+}
+
+func (client *Client) receiveLoop(conn conn) {
+	first := true
+	for {
+		response, err := conn.receive()
+		if err != nil {
+			client.mutex.Lock()
+			client.disconnect(conn, err)
+			client.mutex.Unlock()
+			return
+		}
+
+		client.mutex.Lock()
+		if client.conn != conn {
+			client.mutex.Unlock()
+			return
+		}
+		if first {
+			first = false
+			client.checkHandshakeAck(response)
+		}
+		if len(client.pending) == 0 {
+			logger.Error("Received unexpected DA response")
+		} else {
+			client.pending[0] <- response
+			client.pending = client.pending[1:]
+		}
+		client.mutex.Unlock()
+	}
+}
+
+// disconnect closes the connection, fails all pending reports open and reconnects.
+// Must be called with the mutex held.
+func (client *Client) disconnect(conn conn, err error) {
+	if client.conn != conn {
+		return
+	}
+
+	if !client.closed {
+		logger.ErrorErr(err, "Lost connection to DA at '%s'", client.config.Address)
+	}
+	_ = conn.close()
+	client.conn = nil
+	for _, respChan := range client.pending {
+		close(respChan)
+	}
+	client.pending = nil
+	client.reconnect()
+}
+
+// reconnect connects in the background with exponential backoff. Must be called with the mutex held.
+func (client *Client) reconnect() {
+	if client.connecting || client.closed || client.rejected != nil {
+		return
+	}
+	client.connecting = true
+
+	go func() {
+		backoff := minReconnectBackoff
+		for {
+			time.Sleep(backoff)
+			err := client.connect()
+			if err == nil {
+				return
+			}
+
+			client.mutex.Lock()
+			if client.closed || client.rejected != nil {
+				client.connecting = false
+				client.mutex.Unlock()
+				return
+			}
+			client.mutex.Unlock()
+
+			backoff = util.Min(2*backoff, maxReconnectBackoff)
+			logger.ErrorErr(err, "Could not connect to DA at '%s', retrying in %s", client.config.Address, backoff.String())
+		}
+	}()
+}
+
+// Close disconnects from the DA. Pending reports fail open.
+func (client *Client) Close() error {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.closed = true
+	if client.conn != nil {
+		client.disconnect(client.conn, nil)
+	}
+	return nil
+}
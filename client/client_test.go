@@ -0,0 +1,241 @@
+package client
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/protobuf/proto"
+)
+
+// startFakeDA accepts connections on a unix socket and answers the messages of each connection in order with the
+// response returned by handle. If handle returns nil, the fake DA drops the connection instead.
+func startFakeDA(t *testing.T, handle func(message *protocol.Message) *protocol.Message) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "da.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var connsMutex sync.Mutex
+	var conns []net.Conn
+	t.Cleanup(func() {
+		_ = listener.Close()
+		connsMutex.Lock()
+		defer connsMutex.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			connsMutex.Lock()
+			conns = append(conns, conn)
+			connsMutex.Unlock()
+			go serveFakeDA(conn, handle)
+		}
+	}()
+	return path
+}
+
+func serveFakeDA(conn net.Conn, handle func(message *protocol.Message) *protocol.Message) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		size, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return
+		}
+		messageBytes := make([]byte, size)
+		_, err = io.ReadFull(reader, messageBytes)
+		if err != nil {
+			return
+		}
+		message := &protocol.Message{}
+		err = proto.Unmarshal(messageBytes, message)
+		if err != nil {
+			return
+		}
+
+		response := handle(message)
+		if response == nil {
+			return
+		}
+		responseBytes, err := proto.Marshal(response)
+		if err != nil {
+			return
+		}
+		framed := binary.AppendUvarint(nil, uint64(len(responseBytes)))
+		_, err = conn.Write(append(framed, responseBytes...))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// echo performs the action requested by the node.
+func echo(message *protocol.Message) *protocol.Message {
+	return &protocol.Message{MessageType: protocol.MessageType_DA_RESPONSE, ActionType: message.ActionType}
+}
+
+func newTestClient(t *testing.T, config Config) *Client {
+	t.Helper()
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	config.Transport = TransportUnix
+	config.NodeId = 1
+	daClient, err := NewClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = daClient.Close()
+	})
+	return daClient
+}
+
+func reportVote(daClient *Client, requested protocol.ActionType) (protocol.ActionType, error) {
+	return daClient.ReportAction(protocol.MessageType_VOTE_RECEIVED, &protocol.VoteReceived{VotingNodeId: 2, VotedNodeId: 1, VoteGranted: true}, requested)
+}
+
+func TestReportMatchesResponses(t *testing.T) {
+	daClient := newTestClient(t, Config{Address: startFakeDA(t, echo)})
+
+	actions := []protocol.ActionType{
+		protocol.ActionType_NOOP_ACTION_TYPE,
+		protocol.ActionType_HALT_ACTION_TYPE,
+		protocol.ActionType_PAUSE_ACTION_TYPE,
+		protocol.ActionType_STOP_ACTION_TYPE,
+		protocol.ActionType_RESEND_LAST_MESSAGE_ACTION_TYPE,
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, requested := range actions {
+			wg.Add(1)
+			go func(requested protocol.ActionType) {
+				defer wg.Done()
+				actionType, err := reportVote(daClient, requested)
+				if err != nil {
+					t.Error(err)
+				} else if actionType != requested {
+					t.Errorf("requested %s, got the response %s", requested.String(), actionType.String())
+				}
+			}(requested)
+		}
+	}
+	wg.Wait()
+}
+
+func TestReportFailsOpenOnTimeout(t *testing.T) {
+	release := make(chan struct{})
+	held := atomic.Bool{}
+	path := startFakeDA(t, func(message *protocol.Message) *protocol.Message {
+		if message.MessageType == protocol.MessageType_VOTE_RECEIVED && held.CompareAndSwap(false, true) {
+			<-release
+		}
+		return echo(message)
+	})
+	daClient := newTestClient(t, Config{Address: path, Timeout: 100 * time.Millisecond})
+
+	actionType, err := reportVote(daClient, protocol.ActionType_HALT_ACTION_TYPE)
+	if !errors.Is(err, ErrFailedOpen) {
+		t.Fatalf("expected %v, got %v", ErrFailedOpen, err)
+	}
+	if actionType != protocol.ActionType_NOOP_ACTION_TYPE {
+		t.Fatalf("expected noop on timeout, got %s", actionType.String())
+	}
+
+	// The late response belongs to the timed out report, not to the next one.
+	close(release)
+	actionType, err = reportVote(daClient, protocol.ActionType_PAUSE_ACTION_TYPE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actionType != protocol.ActionType_PAUSE_ACTION_TYPE {
+		t.Fatalf("expected %s, got the response %s", protocol.ActionType_PAUSE_ACTION_TYPE.String(), actionType.String())
+	}
+}
+
+func TestReconnectAfterConnectionLoss(t *testing.T) {
+	dropped := atomic.Bool{}
+	handshakes := atomic.Int64{}
+	path := startFakeDA(t, func(message *protocol.Message) *protocol.Message {
+		switch message.MessageType {
+		case protocol.MessageType_HANDSHAKE:
+			handshakes.Add(1)
+		case protocol.MessageType_VOTE_RECEIVED:
+			if dropped.CompareAndSwap(false, true) {
+				return nil
+			}
+		}
+		return echo(message)
+	})
+	daClient := newTestClient(t, Config{Address: path})
+
+	_, err := reportVote(daClient, protocol.ActionType_STOP_ACTION_TYPE)
+	if !errors.Is(err, ErrFailedOpen) || !errors.Is(err, ErrConnectionLost) {
+		t.Fatalf("expected %v and %v, got %v", ErrFailedOpen, ErrConnectionLost, err)
+	}
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		actionType, err := reportVote(daClient, protocol.ActionType_HALT_ACTION_TYPE)
+		if err == nil {
+			if actionType != protocol.ActionType_HALT_ACTION_TYPE {
+				t.Fatalf("expected %s, got the response %s", protocol.ActionType_HALT_ACTION_TYPE.String(), actionType.String())
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("client did not reconnect within 5s: %v", err)
+		}
+	}
+	if handshakes.Load() != 2 {
+		t.Fatalf("expected a handshake per connection, got %d handshakes", handshakes.Load())
+	}
+}
+
+func TestHeartbeats(t *testing.T) {
+	heartbeats := make(chan struct{}, 16)
+	path := startFakeDA(t, func(message *protocol.Message) *protocol.Message {
+		if message.MessageType == protocol.MessageType_HEARTBEAT {
+			select {
+			case heartbeats <- struct{}{}:
+			default:
+			}
+		}
+		return echo(message)
+	})
+	daClient := newTestClient(t, Config{Address: path, HeartbeatInterval: 10 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-heartbeats:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %d heartbeats within 5s, expected 3", i)
+		}
+	}
+
+	// The responses to the heartbeats do not answer reports.
+	actionType, err := reportVote(daClient, protocol.ActionType_HALT_ACTION_TYPE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actionType != protocol.ActionType_HALT_ACTION_TYPE {
+		t.Fatalf("expected %s, got the response %s", protocol.ActionType_HALT_ACTION_TYPE.String(), actionType.String())
+	}
+}
@@ -0,0 +1,156 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net"
+)
+
+// maxResponseSize guards against corrupted length prefixes, DA responses are small.
+const maxResponseSize = 1024 * 1024
+
+// conn is the connection to the DA on one of its transports.
+type conn interface {
+	send(message *protocol.Message) error
+	receive() (*protocol.Message, error)
+	close() error
+}
+
+func dial(transport string, address string) (conn, error) {
+	switch transport {
+	case TransportUnix, TransportTcp:
+		connection, err := net.Dial(transport, address)
+		if err != nil {
+			return nil, err
+		}
+		return &streamConn{Conn: connection, reader: bufio.NewReader(connection)}, nil
+	case TransportUnixPacket:
+		connection, err := net.Dial(transport, address)
+		if err != nil {
+			return nil, err
+		}
+		return &packetConn{Conn: connection, buffer: make([]byte, maxResponseSize)}, nil
+	case TransportGrpc:
+		return dialGrpc(address)
+	}
+
+	return nil, fmt.Errorf("unsupported transport '%s'", transport)
+}
+
+// streamConn frames messages with a uvarint length prefix.
+type streamConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (conn *streamConn) send(message *protocol.Message) error {
+	messageBytes, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	framed := make([]byte, 0, binary.MaxVarintLen64+len(messageBytes))
+	framed = binary.AppendUvarint(framed, uint64(len(messageBytes)))
+	_, err = conn.Write(append(framed, messageBytes...))
+	return err
+}
+
+func (conn *streamConn) receive() (*protocol.Message, error) {
+	size, err := binary.ReadUvarint(conn.reader)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxResponseSize {
+		return nil, fmt.Errorf("invalid frame length %d", size)
+	}
+
+	messageBytes := make([]byte, size)
+	_, err = io.ReadFull(conn.reader, messageBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &protocol.Message{}
+	return response, proto.Unmarshal(messageBytes, response)
+}
+
+func (conn *streamConn) close() error {
+	return conn.Close()
+}
+
+// packetConn sends every message in its own packet.
+type packetConn struct {
+	net.Conn
+	buffer []byte
+}
+
+func (conn *packetConn) send(message *protocol.Message) error {
+	messageBytes, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write(messageBytes)
+	return err
+}
+
+func (conn *packetConn) receive() (*protocol.Message, error) {
+	bytesRead, err := conn.Read(conn.buffer)
+	if err != nil {
+		return nil, err
+	}
+	if bytesRead == 0 {
+		return nil, io.EOF
+	}
+
+	response := &protocol.Message{}
+	return response, proto.Unmarshal(conn.buffer[:bytesRead], response)
+}
+
+func (conn *packetConn) close() error {
+	return conn.Close()
+}
+
+// grpcConn is a Connect stream of the DistributedAssistant service.
+type grpcConn struct {
+	clientConn *grpc.ClientConn
+	stream     protocol.DistributedAssistant_ConnectClient
+	cancel     context.CancelFunc
+}
+
+func dialGrpc(address string) (conn, error) {
+	clientConn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := protocol.NewDistributedAssistantClient(clientConn).Connect(ctx)
+	if err != nil {
+		cancel()
+		_ = clientConn.Close()
+		return nil, err
+	}
+
+	return &grpcConn{clientConn: clientConn, stream: stream, cancel: cancel}, nil
+}
+
+func (conn *grpcConn) send(message *protocol.Message) error {
+	return conn.stream.Send(message)
+}
+
+func (conn *grpcConn) receive() (*protocol.Message, error) {
+	return conn.stream.Recv()
+}
+
+func (conn *grpcConn) close() error {
+	conn.cancel()
+	return conn.clientConn.Close()
+}
@@ -1,6 +1,7 @@
 unix-to-da-domain-socket-path: "${TO_DA_CONTAINER_SOCKET_PATH}"
 unix-from-da-domain-socket-path: "${FROM_DA_CONTAINER_SOCKET_PATH}"
 faults-enabled: true
+api-address: ":8080"
 actions:
   noop:
     probability: 1.0
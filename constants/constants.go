@@ -4,14 +4,25 @@ import (
 	"github.com/FatProteins/master-thesis-code/network/protocol"
 	"github.com/FatProteins/master-thesis-code/util"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"math"
 )
 
-var voteRequestReceivedSize = proto.Size(&protocol.VoteRequestReceived{})
-var voteReceivedSize = proto.Size(&protocol.VoteReceived{})
-var logEntryReplicatedSize = proto.Size(&protocol.LogEntryReplicated{})
-var logEntryCommitedSize = proto.Size(&protocol.LogEntryCommitted{})
-var followerSuspectedSize = proto.Size(&protocol.FollowerSuspected{})
-var leaderSuspectedSize = proto.Size(&protocol.LeaderSuspected{})
+// DefaultMaxMessageSize is used if no maximum message size is configured.
+const DefaultMaxMessageSize = 40 * 1024
+
+var voteRequestReceivedSize = wrappedSize(&protocol.VoteRequestReceived{RequestingNodeId: math.MaxUint32, ReceivingNodeId: math.MaxUint32, Term: math.MaxUint64})
+var voteReceivedSize = wrappedSize(&protocol.VoteReceived{VotingNodeId: math.MaxUint32, VotedNodeId: math.MaxUint32, VoteGranted: true})
+var logEntryReplicatedSize = wrappedSize(&protocol.LogEntryReplicated{LeaderId: math.MaxUint32, ReceivingNodeId: math.MaxUint32, LogEntryNumber: math.MinInt64})
+var logEntryCommitedSize = wrappedSize(&protocol.LogEntryCommitted{LeaderId: math.MaxUint32, ReceivingNodeId: math.MaxUint32, LogEntryNumber: math.MinInt64})
+var followerSuspectedSize = wrappedSize(&protocol.FollowerSuspected{LeaderId: math.MaxUint32, FollowerId: math.MaxUint32})
+var leaderSuspectedSize = wrappedSize(&protocol.LeaderSuspected{LeaderId: math.MaxUint32, SuspectingNodeId: math.MaxUint32})
+var prePrepareReceivedSize = wrappedSize(&protocol.PrePrepareReceived{LeaderId: math.MaxUint32, ReceivingNodeId: math.MaxUint32, View: math.MaxUint64, SequenceNumber: math.MaxUint64})
+var prepareReceivedSize = wrappedSize(&protocol.PrepareReceived{SendingNodeId: math.MaxUint32, ReceivingNodeId: math.MaxUint32, View: math.MaxUint64, SequenceNumber: math.MaxUint64})
+var commitReceivedSize = wrappedSize(&protocol.CommitReceived{SendingNodeId: math.MaxUint32, ReceivingNodeId: math.MaxUint32, View: math.MaxUint64, SequenceNumber: math.MaxUint64})
+var viewChangeStartedSize = wrappedSize(&protocol.ViewChangeStarted{NodeId: math.MaxUint32, SuspectedLeaderId: math.MaxUint32, NewView: math.MaxUint64})
+var checkpointReachedSize = wrappedSize(&protocol.CheckpointReached{NodeId: math.MaxUint32, SequenceNumber: math.MaxUint64})
+var stateTransferRequestedSize = wrappedSize(&protocol.StateTransferRequested{RequestingNodeId: math.MaxUint32, ReceivingNodeId: math.MaxUint32, LastSequenceNumber: math.MaxUint64})
 var maxMessageSize = util.Max(
 	voteRequestReceivedSize,
 	voteReceivedSize,
@@ -19,8 +30,30 @@ var maxMessageSize = util.Max(
 	logEntryCommitedSize,
 	followerSuspectedSize,
 	leaderSuspectedSize,
+	prePrepareReceivedSize,
+	prepareReceivedSize,
+	commitReceivedSize,
+	viewChangeStartedSize,
+	checkpointReachedSize,
+	stateTransferRequestedSize,
 )
 
+// wrappedSize returns the encoded size of the event wrapped in a protocol.Message as sent by the nodes.
+func wrappedSize(event proto.Message) int {
+	messageObject, err := anypb.New(event)
+	if err != nil {
+		panic(err)
+	}
+
+	return proto.Size(&protocol.Message{
+		MessageType:   protocol.MessageType_FOLLOWER_SUSPECTED,
+		ActionType:    protocol.ActionType_RESEND_LAST_MESSAGE_ACTION_TYPE,
+		MessageObject: messageObject,
+	})
+}
+
+// MaxMessageSize returns the largest encoded size of the known fixed-size protocol messages.
+// Configured maximum message sizes must not be smaller.
 func MaxMessageSize() int {
 	return maxMessageSize
 }
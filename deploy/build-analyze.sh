@@ -0,0 +1,7 @@
+#!/bin/bash
+
+set -e
+
+PROJECT_ROOT=$(pwd | sed 's/master-thesis-code\/deploy.*/master-thesis-code/g')
+
+go build -o "${PROJECT_ROOT}/bin/analyze" "${PROJECT_ROOT}/analyze"
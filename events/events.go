@@ -0,0 +1,112 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	KindMessage = "message"
+	KindAction  = "action"
+	KindFinding = "finding"
+)
+
+const subscriberBufferSize = 1024
+
+// Event is a decoded consensus event, a fault action performed by the DA or a finding about a node, e.g. its
+// unexpected downtime.
+type Event struct {
+	Kind        string          `json:"kind"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Node        uint32          `json:"node"`
+	MessageType string          `json:"messageType"`
+	Action      string          `json:"action,omitempty"`
+	Duration    time.Duration   `json:"duration,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+}
+
+// Filter selects the events a subscriber is interested in. Empty sets match everything.
+type Filter struct {
+	Nodes        map[uint32]bool
+	MessageTypes map[string]bool
+}
+
+func (filter Filter) Matches(event Event) bool {
+	if len(filter.Nodes) != 0 && !filter.Nodes[event.Node] {
+		return false
+	}
+	if len(filter.MessageTypes) != 0 && !filter.MessageTypes[event.MessageType] {
+		return false
+	}
+	return true
+}
+
+type Subscription struct {
+	events  chan Event
+	filter  Filter
+	dropped atomic.Uint64
+}
+
+func (subscription *Subscription) Events() <-chan Event {
+	return subscription.events
+}
+
+// Dropped returns the number of events that were dropped because the subscriber was too slow.
+func (subscription *Subscription) Dropped() uint64 {
+	return subscription.dropped.Load()
+}
+
+// Hub fans out published events to all subscribers. Publishing never blocks; events are dropped
+// for subscribers whose buffer is full.
+type Hub struct {
+	mutex       sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[*Subscription]struct{})}
+}
+
+func (hub *Hub) Subscribe(filter Filter) *Subscription {
+	subscription := &Subscription{events: make(chan Event, subscriberBufferSize), filter: filter}
+	hub.mutex.Lock()
+	hub.subscribers[subscription] = struct{}{}
+	hub.mutex.Unlock()
+	return subscription
+}
+
+func (hub *Hub) Unsubscribe(subscription *Subscription) {
+	hub.mutex.Lock()
+	delete(hub.subscribers, subscription)
+	hub.mutex.Unlock()
+}
+
+// HasSubscribers allows publishers to skip building events nobody listens to.
+func (hub *Hub) HasSubscribers() bool {
+	if hub == nil {
+		return false
+	}
+
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+	return len(hub.subscribers) != 0
+}
+
+func (hub *Hub) Publish(event Event) {
+	hub.mutex.RLock()
+	defer hub.mutex.RUnlock()
+
+	for subscription := range hub.subscribers {
+		if !subscription.filter.Matches(event) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.dropped.Add(1)
+		}
+	}
+}
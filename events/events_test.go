@@ -0,0 +1,121 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, subscription *Subscription) (Event, bool) {
+	t.Helper()
+	select {
+	case event := <-subscription.Events():
+		return event, true
+	default:
+		return Event{}, false
+	}
+}
+
+func TestSubscribeAndUnsubscribe(t *testing.T) {
+	hub := NewHub()
+	if hub.HasSubscribers() {
+		t.Fatal("new hub has subscribers")
+	}
+
+	subscription := hub.Subscribe(Filter{})
+	if !hub.HasSubscribers() {
+		t.Fatal("hub has no subscribers after subscribing")
+	}
+	hub.Publish(Event{Kind: KindMessage, Node: 1})
+	if event, ok := receive(t, subscription); !ok || event.Node != 1 {
+		t.Fatalf("got %+v, want the published event", event)
+	}
+
+	hub.Unsubscribe(subscription)
+	if hub.HasSubscribers() {
+		t.Fatal("hub has subscribers after unsubscribing")
+	}
+	hub.Publish(Event{Kind: KindMessage, Node: 2})
+	if event, ok := receive(t, subscription); ok {
+		t.Fatalf("got %+v after unsubscribing", event)
+	}
+}
+
+func TestNilHubHasNoSubscribers(t *testing.T) {
+	var hub *Hub
+	if hub.HasSubscribers() {
+		t.Fatal("nil hub has subscribers")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		event   Event
+		matches bool
+	}{
+		{"empty filter", Filter{}, Event{Node: 1, MessageType: "VOTE_RECEIVED"}, true},
+		{"node", Filter{Nodes: map[uint32]bool{1: true}}, Event{Node: 1, MessageType: "VOTE_RECEIVED"}, true},
+		{"other node", Filter{Nodes: map[uint32]bool{1: true}}, Event{Node: 2, MessageType: "VOTE_RECEIVED"}, false},
+		{"message type", Filter{MessageTypes: map[string]bool{"VOTE_RECEIVED": true}}, Event{Node: 2, MessageType: "VOTE_RECEIVED"}, true},
+		{"other message type", Filter{MessageTypes: map[string]bool{"VOTE_RECEIVED": true}}, Event{Node: 2, MessageType: "LEADER_SUSPECTED"}, false},
+		{"node and message type", Filter{Nodes: map[uint32]bool{1: true}, MessageTypes: map[string]bool{"VOTE_RECEIVED": true}}, Event{Node: 1, MessageType: "VOTE_RECEIVED"}, true},
+		{"node but other message type", Filter{Nodes: map[uint32]bool{1: true}, MessageTypes: map[string]bool{"VOTE_RECEIVED": true}}, Event{Node: 1, MessageType: "LEADER_SUSPECTED"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches := test.filter.Matches(test.event); matches != test.matches {
+				t.Errorf("filter matches %v, want %v", matches, test.matches)
+			}
+
+			hub := NewHub()
+			subscription := hub.Subscribe(test.filter)
+			hub.Publish(test.event)
+			if _, received := receive(t, subscription); received != test.matches {
+				t.Errorf("subscriber received the event %v, want %v", received, test.matches)
+			}
+		})
+	}
+}
+
+// TestSlowSubscriberDropsEvents publishes more events than a subscriber that does not read buffers. Publishing
+// must not block, the events beyond the buffer are dropped for the slow subscriber only.
+func TestSlowSubscriberDropsEvents(t *testing.T) {
+	hub := NewHub()
+	slow := hub.Subscribe(Filter{})
+	fast := hub.Subscribe(Filter{})
+
+	published := make(chan int)
+	go func() {
+		received := 0
+		for i := 0; i < subscriberBufferSize+10; i++ {
+			hub.Publish(Event{Kind: KindMessage, Node: uint32(i)})
+			if event := <-fast.Events(); event.Node == uint32(i) {
+				received++
+			}
+		}
+		published <- received
+	}()
+	select {
+	case received := <-published:
+		if received != subscriberBufferSize+10 {
+			t.Errorf("fast subscriber received %d events, want %d", received, subscriberBufferSize+10)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing blocked on the slow subscriber")
+	}
+
+	if dropped := slow.Dropped(); dropped != 10 {
+		t.Errorf("slow subscriber dropped %d events, want 10", dropped)
+	}
+	if buffered := len(slow.Events()); buffered != subscriberBufferSize {
+		t.Errorf("slow subscriber buffered %d events, want %d", buffered, subscriberBufferSize)
+	}
+	if event, _ := receive(t, slow); event.Node != 0 {
+		t.Errorf("slow subscriber got node %d first, want the oldest event", event.Node)
+	}
+	if dropped := fast.Dropped(); dropped != 0 {
+		t.Errorf("fast subscriber dropped %d events", dropped)
+	}
+}
@@ -0,0 +1,137 @@
+package faultlog
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event is a single fault injected by the DA.
+type Event struct {
+	Action      string
+	Node        uint32
+	Start       time.Time
+	End         time.Time
+	MessageType string
+	Message     string
+}
+
+var header = []string{"action", "node", "timestampStart", "timestampEnd", "messageType", "message"}
+
+// Writer appends fault events to a CSV file. Timestamps are written as unix nanoseconds,
+// like the client histories written by masternode.
+type Writer struct {
+	mutex  sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+}
+
+func NewWriter(path string) (*Writer, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	writer := csv.NewWriter(file)
+	if info.Size() == 0 {
+		err = writer.Write(header)
+		if err == nil {
+			writer.Flush()
+			err = writer.Error()
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	return &Writer{file: file, writer: writer}, nil
+}
+
+func (w *Writer) Write(event Event) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	err := w.writer.Write([]string{
+		event.Action,
+		strconv.FormatUint(uint64(event.Node), 10),
+		strconv.FormatInt(event.Start.UnixNano(), 10),
+		strconv.FormatInt(event.End.UnixNano(), 10),
+		event.MessageType,
+		event.Message,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *Writer) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.writer.Flush()
+	return errors.Join(w.writer.Error(), w.file.Close())
+}
+
+// Read returns all events of a fault log written by Writer.
+func Read(path string) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = len(header)
+	_, err = reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read header of '%s': %w", path, err)
+	}
+
+	var events []Event
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read fault log '%s': %w", path, err)
+		}
+
+		node, err := strconv.ParseUint(record[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node in fault log '%s': %w", path, err)
+		}
+		start, err := strconv.ParseInt(record[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestampStart in fault log '%s': %w", path, err)
+		}
+		end, err := strconv.ParseInt(record[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestampEnd in fault log '%s': %w", path, err)
+		}
+
+		events = append(events, Event{
+			Action:      record[0],
+			Node:        uint32(node),
+			Start:       time.Unix(0, start),
+			End:         time.Unix(0, end),
+			MessageType: record[4],
+			Message:     record[5],
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Package liveness tracks whether the instrumented nodes are alive by their heartbeats. Downtime caused by the
+// DA itself, e.g. by pausing or stopping a node, is expected. A node that stops sending heartbeats otherwise is
+// reported as unexpected downtime, i.e. as a crash that was not injected.
+package liveness
+
+import (
+	"context"
+	"github.com/FatProteins/master-thesis-code/events"
+	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/metrics"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"sort"
+	"sync"
+	"time"
+)
+
+var logger = daLogger.NewLogger("liveness")
+
+const (
+	StateAlive        = "alive"
+	StateInduced      = "induced"
+	StateUnresponsive = "unresponsive"
+)
+
+// NodeStatus is the liveness of a node that sent at least one heartbeat.
+type NodeStatus struct {
+	Node          uint32    `json:"node"`
+	State         string    `json:"state"`
+	LastHeartbeat time.Time `json:"lastHeartbeat"`
+	InducedBy     string    `json:"inducedBy,omitempty"`
+	Downtimes     uint64    `json:"unexpectedDowntimes"`
+}
+
+type nodeLiveness struct {
+	lastHeartbeat time.Time
+	inducedBy     string
+	inducedEnd    time.Time
+	silentSince   time.Time
+	unresponsive  bool
+	downtimes     uint64
+}
+
+// Monitor expects a heartbeat of every node at least once per timeout. Nodes are monitored from their first
+// heartbeat on, so nodes without heartbeats are never reported. All methods are safe to call on a nil Monitor,
+// which monitors nothing.
+type Monitor struct {
+	timeout  time.Duration
+	eventHub *events.Hub
+	mutex    sync.Mutex
+	nodes    map[uint32]*nodeLiveness
+}
+
+func NewMonitor(timeout time.Duration, eventHub *events.Hub) *Monitor {
+	return &Monitor{timeout: timeout, eventHub: eventHub, nodes: make(map[uint32]*nodeLiveness)}
+}
+
+func (monitor *Monitor) RunAsync(ctx context.Context) {
+	if monitor == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(monitor.timeout / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				monitor.check(now)
+			}
+		}
+	}()
+}
+
+// Heartbeat records a heartbeat of the node.
+func (monitor *Monitor) Heartbeat(node uint32) {
+	if monitor == nil {
+		return
+	}
+
+	now := time.Now()
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+
+	liveness, ok := monitor.nodes[node]
+	if !ok {
+		logger.Info("Monitoring liveness of node %d", node)
+		liveness = &nodeLiveness{}
+		monitor.nodes[node] = liveness
+	}
+	liveness.lastHeartbeat = now
+	if liveness.unresponsive {
+		liveness.unresponsive = false
+		metrics.UnresponsiveNodes.Dec()
+		logger.Info("Node %d is alive again after %s of unexpected downtime", node, now.Sub(liveness.silentSince).String())
+	}
+}
+
+// BeginDowntime marks the node as faulted by the DA, missing heartbeats are expected until EndDowntime.
+func (monitor *Monitor) BeginDowntime(node uint32, action string) {
+	if monitor == nil {
+		return
+	}
+
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	liveness, ok := monitor.nodes[node]
+	if !ok {
+		return
+	}
+	liveness.inducedBy = action
+}
+
+// EndDowntime ends the downtime caused by the DA. The node is given another timeout to send its next heartbeat,
+// e.g. to restart and reconnect after a stop.
+func (monitor *Monitor) EndDowntime(node uint32) {
+	if monitor == nil {
+		return
+	}
+
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	liveness, ok := monitor.nodes[node]
+	if !ok || len(liveness.inducedBy) == 0 {
+		return
+	}
+	liveness.inducedBy = ""
+	liveness.inducedEnd = time.Now()
+}
+
+// Status returns the liveness of all monitored nodes ordered by node.
+func (monitor *Monitor) Status() []NodeStatus {
+	statuses := make([]NodeStatus, 0)
+	if monitor == nil {
+		return statuses
+	}
+
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+	for node, liveness := range monitor.nodes {
+		status := NodeStatus{
+			Node:          node,
+			State:         StateAlive,
+			LastHeartbeat: liveness.lastHeartbeat,
+			InducedBy:     liveness.inducedBy,
+			Downtimes:     liveness.downtimes,
+		}
+		if len(liveness.inducedBy) != 0 {
+			status.State = StateInduced
+		} else if liveness.unresponsive {
+			status.State = StateUnresponsive
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Node < statuses[j].Node })
+	return statuses
+}
+
+// check reports every node that missed its heartbeats without being faulted by the DA.
+func (monitor *Monitor) check(now time.Time) {
+	monitor.mutex.Lock()
+	defer monitor.mutex.Unlock()
+
+	for node, liveness := range monitor.nodes {
+		if liveness.unresponsive || len(liveness.inducedBy) != 0 {
+			continue
+		}
+
+		silentSince := liveness.lastHeartbeat
+		if liveness.inducedEnd.After(silentSince) {
+			silentSince = liveness.inducedEnd
+		}
+		if now.Sub(silentSince) <= monitor.timeout {
+			continue
+		}
+
+		liveness.unresponsive = true
+		liveness.silentSince = silentSince
+		liveness.downtimes++
+		metrics.UnexpectedDowntimes.Inc()
+		metrics.UnresponsiveNodes.Inc()
+		logger.Error("Node %d sent no heartbeat for %s without a fault injected by the DA, reporting unexpected downtime", node, now.Sub(silentSince).String())
+		if monitor.eventHub != nil {
+			monitor.eventHub.Publish(events.Event{
+				Kind:        events.KindFinding,
+				Timestamp:   now,
+				Node:        node,
+				MessageType: protocol.MessageType_HEARTBEAT.String(),
+				Duration:    now.Sub(silentSince),
+			})
+		}
+	}
+}
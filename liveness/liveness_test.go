@@ -0,0 +1,95 @@
+package liveness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/events"
+	"github.com/FatProteins/master-thesis-code/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testTimeout = time.Second
+
+// newTestMonitor returns a monitor of node 1, which sent its last heartbeat more than a timeout ago, and a
+// subscription to its findings. The monitor is not run, the tests call check themselves.
+func newTestMonitor(t *testing.T) (*Monitor, *events.Subscription) {
+	t.Helper()
+	hub := events.NewHub()
+	monitor := NewMonitor(testTimeout, hub)
+	monitor.Heartbeat(1)
+	monitor.nodes[1].lastHeartbeat = time.Now().Add(-2 * testTimeout)
+	return monitor, hub.Subscribe(events.Filter{})
+}
+
+func expectState(t *testing.T, monitor *Monitor, state string, downtimes uint64) {
+	t.Helper()
+	statuses := monitor.Status()
+	if len(statuses) != 1 || statuses[0].State != state || statuses[0].Downtimes != downtimes {
+		t.Fatalf("got %+v, want node 1 %s with %d unexpected downtimes", statuses, state, downtimes)
+	}
+}
+
+func expectNoFinding(t *testing.T, subscription *events.Subscription) {
+	t.Helper()
+	select {
+	case event := <-subscription.Events():
+		t.Fatalf("got finding %+v, want none", event)
+	default:
+	}
+}
+
+func TestMissedHeartbeatDuringDowntimeIsInduced(t *testing.T) {
+	monitor, subscription := newTestMonitor(t)
+	before := testutil.ToFloat64(metrics.UnexpectedDowntimes)
+
+	monitor.BeginDowntime(1, "Pause")
+	monitor.check(time.Now())
+	expectState(t, monitor, StateInduced, 0)
+	if statuses := monitor.Status(); statuses[0].InducedBy != "Pause" {
+		t.Errorf("downtime induced by '%s', want 'Pause'", statuses[0].InducedBy)
+	}
+	expectNoFinding(t, subscription)
+	if after := testutil.ToFloat64(metrics.UnexpectedDowntimes); after != before {
+		t.Errorf("counted %v unexpected downtimes during an induced one", after-before)
+	}
+}
+
+func TestMissedHeartbeatRaisesFinding(t *testing.T) {
+	monitor, subscription := newTestMonitor(t)
+	before := testutil.ToFloat64(metrics.UnexpectedDowntimes)
+
+	monitor.check(time.Now())
+	expectState(t, monitor, StateUnresponsive, 1)
+	select {
+	case event := <-subscription.Events():
+		if event.Kind != events.KindFinding || event.Node != 1 || event.Duration <= testTimeout {
+			t.Errorf("got %+v, want a finding of node 1 silent for more than %s", event, testTimeout)
+		}
+	default:
+		t.Fatal("got no finding")
+	}
+	if after := testutil.ToFloat64(metrics.UnexpectedDowntimes); after != before+1 {
+		t.Errorf("counted %v unexpected downtimes, want 1", after-before)
+	}
+
+	// The downtime is reported once, until the node is alive again.
+	monitor.check(time.Now())
+	expectNoFinding(t, subscription)
+	monitor.Heartbeat(1)
+	expectState(t, monitor, StateAlive, 1)
+}
+
+func TestTimerRestartsAfterEndDowntime(t *testing.T) {
+	monitor, subscription := newTestMonitor(t)
+
+	monitor.BeginDowntime(1, "Stop")
+	monitor.EndDowntime(1)
+	// The last heartbeat is older than the timeout, but the node has another timeout after the downtime.
+	monitor.check(time.Now())
+	expectState(t, monitor, StateAlive, 0)
+	expectNoFinding(t, subscription)
+
+	monitor.check(time.Now().Add(2 * testTimeout))
+	expectState(t, monitor, StateUnresponsive, 1)
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelError
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+func (level Level) String() string {
+	switch level {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int32(level))
+}
+
+func ParseLevel(level string) (Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "error":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level '%s', must be 'debug', 'info' or 'error'", level)
+}
+
+// Levels is the default level and the overrides per package, i.e. per logger prefix.
+type Levels struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages,omitempty"`
+}
+
+var (
+	defaultLevel atomic.Int32
+	jsonFormat   atomic.Bool
+
+	overridesMutex sync.RWMutex
+	overrides      = make(map[string]Level)
+)
+
+func init() {
+	defaultLevel.Store(int32(LevelInfo))
+}
+
+// SetLevel sets the level of all packages without override. It may be changed at runtime.
+func SetLevel(level Level) {
+	defaultLevel.Store(int32(level))
+}
+
+// SetPackageLevel overrides the level of the loggers with the prefix.
+func SetPackageLevel(prefix string, level Level) {
+	overridesMutex.Lock()
+	defer overridesMutex.Unlock()
+	overrides[prefix] = level
+}
+
+// ResetPackageLevels removes all package overrides.
+func ResetPackageLevels() {
+	overridesMutex.Lock()
+	defer overridesMutex.Unlock()
+	overrides = make(map[string]Level)
+}
+
+// SetFormat switches between human-readable text output and one JSON object per line.
+func SetFormat(format string) error {
+	switch format {
+	case FormatText:
+		jsonFormat.Store(false)
+	case FormatJson:
+		jsonFormat.Store(true)
+	default:
+		return fmt.Errorf("unknown log format '%s', must be '%s' or '%s'", format, FormatText, FormatJson)
+	}
+	return nil
+}
+
+// Configure sets the default level and replaces the package overrides.
+func Configure(levels Levels) error {
+	level, err := ParseLevel(levels.Level)
+	if err != nil {
+		return err
+	}
+
+	packageLevels := make(map[string]Level, len(levels.Packages))
+	for prefix, packageLevel := range levels.Packages {
+		packageLevels[prefix], err = ParseLevel(packageLevel)
+		if err != nil {
+			return fmt.Errorf("package '%s': %w", prefix, err)
+		}
+	}
+
+	SetLevel(level)
+	overridesMutex.Lock()
+	overrides = packageLevels
+	overridesMutex.Unlock()
+	return nil
+}
+
+// CurrentLevels returns the default level and the package overrides.
+func CurrentLevels() Levels {
+	levels := Levels{Level: Level(defaultLevel.Load()).String(), Packages: make(map[string]string)}
+	overridesMutex.RLock()
+	defer overridesMutex.RUnlock()
+	for prefix, level := range overrides {
+		levels.Packages[prefix] = level.String()
+	}
+	return levels
+}
+
+func levelOf(prefix string) Level {
+	overridesMutex.RLock()
+	level, ok := overrides[prefix]
+	overridesMutex.RUnlock()
+	if ok {
+		return level
+	}
+	return Level(defaultLevel.Load())
+}
@@ -1,51 +1,149 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Field is a key/value pair attached to log entries, e.g. the node a message was received from.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func Any(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+func Node(node uint32) Field {
+	return Field{Key: "node", Value: node}
+}
+
+func MessageType(messageType string) Field {
+	return Field{Key: "messageType", Value: messageType}
+}
+
+func Action(action string) Field {
+	return Field{Key: "action", Value: action}
+}
+
+func Duration(duration time.Duration) Field {
+	return Field{Key: "duration", Value: duration}
+}
+
+// Logger writes info and debug entries to stdout and errors to stderr, either as text or as JSON. Its level is the
+// level of its package, see SetLevel and SetPackageLevel.
 type Logger struct {
-	stdout *log.Logger
-	stderr *log.Logger
+	prefix string
+	fields []Field
+}
+
+var outputMutex sync.Mutex
+var stdout io.Writer = os.Stdout
+var stderr io.Writer = os.Stderr
+
+func NewLogger(prefix string) *Logger {
+	return &Logger{prefix: prefix}
+}
+
+// With returns a logger attaching the fields to all of its entries.
+func (logger *Logger) With(fields ...Field) *Logger {
+	withFields := make([]Field, 0, len(logger.fields)+len(fields))
+	withFields = append(withFields, logger.fields...)
+	withFields = append(withFields, fields...)
+	return &Logger{prefix: logger.prefix, fields: withFields}
+}
+
+// Enabled reports whether entries of the level are written, e.g. to skip preparing expensive arguments.
+func (logger *Logger) Enabled(level Level) bool {
+	return level >= levelOf(logger.prefix)
+}
+
+// Log writes an entry of the level, e.g. of a level depending on the entry.
+func (logger *Logger) Log(level Level, format string, args ...any) {
+	logger.log(level, nil, format, args)
 }
 
 func (logger *Logger) Debug(format string, args ...any) {
-	return
-	if len(args) == 0 {
-		logger.stdout.Println(format)
-	} else {
-		logger.stdout.Printf(format+"\n", args...)
-	}
+	logger.log(LevelDebug, nil, format, args)
 }
 
 func (logger *Logger) Info(format string, args ...any) {
-	if len(args) == 0 {
-		logger.stdout.Println(format)
-	} else {
-		logger.stdout.Printf(format+"\n", args...)
-	}
+	logger.log(LevelInfo, nil, format, args)
 }
 
 func (logger *Logger) Error(format string, args ...any) {
-	if len(args) == 0 {
-		logger.stderr.Println(format)
-	} else {
-		logger.stderr.Printf(format+"\n", args...)
-	}
+	logger.log(LevelError, nil, format, args)
 }
 
 func (logger *Logger) ErrorErr(err error, format string, args ...any) {
-	if len(args) == 0 {
-		logger.stderr.Println(format + "\n" + err.Error())
+	logger.log(LevelError, err, format, args)
+}
+
+func (logger *Logger) log(level Level, err error, format string, args []any) {
+	if !logger.Enabled(level) {
+		return
+	}
+
+	message := format
+	if len(args) != 0 {
+		message = fmt.Sprintf(format, args...)
+	}
+
+	var entry []byte
+	if jsonFormat.Load() {
+		entry = logger.jsonEntry(level, err, message)
 	} else {
-		logger.stderr.Printf(format+"\n"+err.Error()+"\n", args...)
+		entry = logger.textEntry(level, err, message)
 	}
+
+	output := stdout
+	if level == LevelError {
+		output = stderr
+	}
+	outputMutex.Lock()
+	_, _ = output.Write(entry)
+	outputMutex.Unlock()
 }
 
-func NewLogger(prefix string) *Logger {
-	stdoutLogger := log.New(os.Stdout, fmt.Sprintf("[ INFO | %s ] ", prefix), log.Ldate|log.Ltime)
-	stderrLogger := log.New(os.Stderr, fmt.Sprintf("[ ERROR | %s ] ", prefix), log.Ldate|log.Ltime)
-	return &Logger{stdoutLogger, stderrLogger}
+func (logger *Logger) textEntry(level Level, err error, message string) []byte {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("[ %s | %s ] %s %s", strings.ToUpper(level.String()), logger.prefix, time.Now().Format("2006/01/02 15:04:05"), message))
+	for _, field := range logger.fields {
+		builder.WriteString(fmt.Sprintf(" %s=%v", field.Key, field.Value))
+	}
+	if err != nil {
+		builder.WriteString("\n" + err.Error())
+	}
+	builder.WriteString("\n")
+	return []byte(builder.String())
+}
+
+func (logger *Logger) jsonEntry(level Level, err error, message string) []byte {
+	entry := make(map[string]any, len(logger.fields)+5)
+	for _, field := range logger.fields {
+		value := field.Value
+		if duration, ok := value.(time.Duration); ok {
+			value = duration.String()
+		}
+		entry[field.Key] = value
+	}
+	entry["time"] = time.Now().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["logger"] = logger.prefix
+	entry["msg"] = message
+	if err != nil {
+		entry["error"] = err.Error()
+	}
+
+	entryJson, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		entryJson, _ = json.Marshal(map[string]any{"time": entry["time"], "level": entry["level"], "logger": logger.prefix, "msg": message})
+	}
+	return append(entryJson, '\n')
 }
@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureOutput redirects the loggers to buffers and restores the output, the levels and the format after the test.
+func captureOutput(t *testing.T) (*bytes.Buffer, *bytes.Buffer) {
+	t.Helper()
+	previousStdout, previousStderr := stdout, stderr
+	out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+	stdout, stderr = out, errOut
+	t.Cleanup(func() {
+		stdout, stderr = previousStdout, previousStderr
+		SetLevel(LevelInfo)
+		ResetPackageLevels()
+		_ = SetFormat(FormatText)
+	})
+	return out, errOut
+}
+
+func TestPackageLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		levels    Levels
+		prefix    string
+		level     Level
+		writes    bool
+		errWrites bool
+	}{
+		{name: "info by default", levels: Levels{Level: "info"}, prefix: "process", level: LevelInfo, writes: true},
+		{name: "debug below default", levels: Levels{Level: "info"}, prefix: "process", level: LevelDebug},
+		{name: "error above default", levels: Levels{Level: "info"}, prefix: "process", level: LevelError, errWrites: true},
+		{name: "package override lowers level", levels: Levels{Level: "error", Packages: map[string]string{"process": "debug"}}, prefix: "process", level: LevelDebug, writes: true},
+		{name: "package override raises level", levels: Levels{Level: "debug", Packages: map[string]string{"process": "error"}}, prefix: "process", level: LevelInfo},
+		{name: "override of other package", levels: Levels{Level: "error", Packages: map[string]string{"network": "debug"}}, prefix: "process", level: LevelInfo},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, errOut := captureOutput(t)
+			err := Configure(test.levels)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			logger := NewLogger(test.prefix)
+			if enabled := logger.Enabled(test.level); enabled != (test.writes || test.errWrites) {
+				t.Errorf("%s enabled is %v, want %v", test.level.String(), enabled, test.writes || test.errWrites)
+			}
+			logger.Log(test.level, "entry")
+			if written := out.Len() != 0; written != test.writes {
+				t.Errorf("wrote %q to stdout, want an entry %v", out.String(), test.writes)
+			}
+			if written := errOut.Len() != 0; written != test.errWrites {
+				t.Errorf("wrote %q to stderr, want an entry %v", errOut.String(), test.errWrites)
+			}
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	captureOutput(t)
+	err := Configure(Levels{Level: "debug", Packages: map[string]string{"network": "error"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	levels := CurrentLevels()
+	if levels.Level != "debug" || len(levels.Packages) != 1 || levels.Packages["network"] != "error" {
+		t.Errorf("got levels %+v after configuring them", levels)
+	}
+
+	for _, invalid := range []Levels{{Level: "verbose"}, {Level: "info", Packages: map[string]string{"network": "verbose"}}} {
+		if err := Configure(invalid); err == nil {
+			t.Errorf("configured invalid levels %+v", invalid)
+		}
+	}
+	if levels := CurrentLevels(); levels.Level != "debug" || levels.Packages["network"] != "error" {
+		t.Errorf("invalid levels changed the levels to %+v", levels)
+	}
+}
+
+func TestTextFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   []Field
+		err      error
+		expected string
+	}{
+		{name: "no fields", expected: " message\n"},
+		{name: "fields", fields: []Field{Node(1), MessageType("VOTE_RECEIVED")}, expected: " message node=1 messageType=VOTE_RECEIVED\n"},
+		{name: "duration", fields: []Field{Action("Halt"), Duration(1500 * time.Millisecond)}, expected: " message action=Halt duration=1.5s\n"},
+		{name: "error", fields: []Field{Any("attempt", 2)}, err: errors.New("boom"), expected: " message attempt=2\nboom\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, errOut := captureOutput(t)
+			NewLogger("test").With(test.fields...).ErrorErr(test.err, "message")
+			entry := errOut.String()
+			if !strings.HasPrefix(entry, "[ ERROR | test ] ") || !strings.HasSuffix(entry, test.expected) {
+				t.Errorf("got %q, want an error entry of 'test' ending in %q", entry, test.expected)
+			}
+		})
+	}
+}
+
+func TestJsonFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      func(logger *Logger)
+		stderr   bool
+		expected map[string]any
+	}{
+		{
+			name:     "info",
+			log:      func(logger *Logger) { logger.Info("handled %d messages", 3) },
+			expected: map[string]any{"level": "info", "logger": "process", "msg": "handled 3 messages"},
+		},
+		{
+			name:     "fields",
+			log:      func(logger *Logger) { logger.With(Node(1), Duration(1500*time.Millisecond)).Info("done") },
+			expected: map[string]any{"level": "info", "logger": "process", "msg": "done", "node": float64(1), "duration": "1.5s"},
+		},
+		{
+			name:     "error",
+			log:      func(logger *Logger) { logger.With(Action("Stop")).ErrorErr(errors.New("boom"), "failed") },
+			stderr:   true,
+			expected: map[string]any{"level": "error", "logger": "process", "msg": "failed", "action": "Stop", "error": "boom"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, errOut := captureOutput(t)
+			err := SetFormat(FormatJson)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.log(NewLogger("process"))
+
+			output := out
+			if test.stderr {
+				output = errOut
+			}
+			entry := make(map[string]any)
+			err = json.Unmarshal(output.Bytes(), &entry)
+			if err != nil {
+				t.Fatalf("entry %q is no JSON object: %v", output.String(), err)
+			}
+			timestamp, ok := entry["time"].(string)
+			if _, err := time.Parse(time.RFC3339Nano, timestamp); !ok || err != nil {
+				t.Errorf("got time %v, want an RFC 3339 timestamp", entry["time"])
+			}
+			delete(entry, "time")
+			if len(entry) != len(test.expected) {
+				t.Errorf("got entry %v, want %v", entry, test.expected)
+			}
+			for key, value := range test.expected {
+				if entry[key] != value {
+					t.Errorf("got %s %v, want %v", key, entry[key], value)
+				}
+			}
+		})
+	}
+
+	if err := SetFormat("xml"); err == nil {
+		t.Error("set unknown format 'xml'")
+	}
+}
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "da"
+
+var (
+	MessagesReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_received_total",
+		Help:      "Number of messages received from the instrumented node per message type.",
+	}, []string{"message_type"})
+
+	UndecodableMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "undecodable_messages_total",
+		Help:      "Number of messages whose payload could not be decoded per message type.",
+	}, []string{"message_type"})
+
+	ActionsPerformed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_performed_total",
+		Help:      "Number of performed fault actions per action.",
+	}, []string{"action"})
+
+	ActionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "action_duration_seconds",
+		Help:      "Time spent performing a fault action.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 14),
+	}, []string{"action"})
+
+	HandlingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handling_duration_seconds",
+		Help:      "Time from reading a message from the socket until writing its response per message type and action.",
+		Buckets:   prometheus.ExponentialBuckets(0.00001, 4, 14),
+	}, []string{"message_type", "action"})
+
+	HandlingOverhead = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handling_overhead_seconds",
+		Help:      "Time the DA adds to handling a message besides performing its action and breakpoints per message type.",
+		Buckets:   prometheus.ExponentialBuckets(0.000001, 2, 24),
+	}, []string{"message_type"})
+
+	NoopBudgetExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "noop_budget_exceeded_total",
+		Help:      "Number of noop messages whose handling exceeded the configured budget per message type.",
+	}, []string{"message_type"})
+
+	FaultCommandFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fault_command_failures_total",
+		Help:      "Number of external fault commands that failed per command.",
+	}, []string{"command"})
+
+	Connections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "connections",
+		Help:      "Number of currently connected instrumented nodes.",
+	})
+
+	ConnectionState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "connection_state",
+		Help:      "State of the DA socket, 1 for the current state and 0 for all others.",
+	}, []string{"state"})
+
+	ListenFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "listen_failures_total",
+		Help:      "Number of failed attempts to listen on or accept from the DA socket.",
+	})
+
+	DroppedResponses = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dropped_responses_total",
+		Help:      "Number of responses dropped because the connection of the message was closed.",
+	})
+
+	MessageMisuses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "message_misuses_total",
+		Help:      "Number of messages used after they were freed or responded to twice, per misuse.",
+	}, []string{"misuse"})
+
+	SocketResets = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "socket_resets_total",
+		Help:      "Number of times the connection to the instrumented node was reset.",
+	})
+
+	OversizedMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "oversized_messages_total",
+		Help:      "Number of messages dropped because they exceeded the maximum message size.",
+	})
+
+	ResponseMarshalErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "response_marshal_errors_total",
+		Help:      "Number of DA responses that could not be marshalled.",
+	})
+
+	RejectedHandshakes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rejected_handshakes_total",
+		Help:      "Number of nodes rejected because of an incompatible protocol version.",
+	})
+
+	UnsupportedActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unsupported_actions_total",
+		Help:      "Number of decided actions replaced by noop because the node cannot apply them.",
+	}, []string{"action"})
+
+	HeartbeatsReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "heartbeats_received_total",
+		Help:      "Number of heartbeats received from instrumented nodes.",
+	})
+
+	UnexpectedDowntimes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unexpected_downtimes_total",
+		Help:      "Number of times a node stopped sending heartbeats without a fault injected by the DA.",
+	})
+
+	UnresponsiveNodes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "unresponsive_nodes",
+		Help:      "Number of nodes currently missing heartbeats without a fault injected by the DA.",
+	})
+)
+
+// RegisterQueueLength exposes the current length of a queue, e.g. the messages queued at the processor, as gauge.
+func RegisterQueueLength(queue string, length func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "queue_length",
+		Help:        "Number of unread entries in a DA queue.",
+		ConstLabels: prometheus.Labels{"queue": queue},
+	}, func() float64 {
+		return float64(length())
+	})
+}
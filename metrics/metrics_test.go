@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// labelled are the vectors with their label names. A child with every label set to "test" is created to gather
+// the vector, and deleted again.
+var labelled = []struct {
+	name   string
+	vec    *prometheus.MetricVec
+	labels []string
+}{
+	{"da_messages_received_total", MessagesReceived.MetricVec, []string{"message_type"}},
+	{"da_undecodable_messages_total", UndecodableMessages.MetricVec, []string{"message_type"}},
+	{"da_actions_performed_total", ActionsPerformed.MetricVec, []string{"action"}},
+	{"da_action_duration_seconds", ActionDuration.MetricVec, []string{"action"}},
+	{"da_handling_duration_seconds", HandlingDuration.MetricVec, []string{"message_type", "action"}},
+	{"da_handling_overhead_seconds", HandlingOverhead.MetricVec, []string{"message_type"}},
+	{"da_noop_budget_exceeded_total", NoopBudgetExceeded.MetricVec, []string{"message_type"}},
+	{"da_unsupported_actions_total", UnsupportedActions.MetricVec, []string{"action"}},
+	{"da_message_misuses_total", MessageMisuses.MetricVec, []string{"misuse"}},
+	{"da_connection_state", ConnectionState.MetricVec, []string{"state"}},
+}
+
+var unlabelled = map[string]prometheus.Collector{
+	"da_connections":                   Connections,
+	"da_dropped_responses_total":       DroppedResponses,
+	"da_response_marshal_errors_total": ResponseMarshalErrors,
+	"da_oversized_messages_total":      OversizedMessages,
+	"da_heartbeats_received_total":     HeartbeatsReceived,
+	"da_unexpected_downtimes_total":    UnexpectedDowntimes,
+	"da_unresponsive_nodes":            UnresponsiveNodes,
+}
+
+func TestLabelledMetricsAreRegistered(t *testing.T) {
+	for _, metric := range labelled {
+		t.Run(metric.name, func(t *testing.T) {
+			values := make([]string, len(metric.labels))
+			for i := range values {
+				values[i] = "test"
+			}
+			_, err := metric.vec.GetMetricWithLabelValues(values...)
+			if err != nil {
+				t.Fatalf("labels %v: %v", metric.labels, err)
+			}
+			defer metric.vec.DeleteLabelValues(values...)
+
+			families, err := prometheus.DefaultGatherer.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, family := range families {
+				if family.GetName() != metric.name {
+					continue
+				}
+				for _, child := range family.GetMetric() {
+					labels := make([]string, 0, len(child.GetLabel()))
+					for _, label := range child.GetLabel() {
+						labels = append(labels, label.GetName())
+					}
+					sort.Strings(labels)
+					expected := append([]string(nil), metric.labels...)
+					sort.Strings(expected)
+					if !reflect.DeepEqual(labels, expected) {
+						t.Errorf("got labels %v, want %v", labels, expected)
+					}
+				}
+				return
+			}
+			t.Errorf("'%s' is not registered", metric.name)
+		})
+	}
+}
+
+func TestUnlabelledMetricsAreRegistered(t *testing.T) {
+	for name, collector := range unlabelled {
+		count, err := testutil.GatherAndCount(prometheus.DefaultGatherer, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("'%s' is registered %d times, want once", name, count)
+		}
+		if collected := testutil.CollectAndCount(collector, name); collected != 1 {
+			t.Errorf("'%s' collects %d metrics, want 1", name, collected)
+		}
+	}
+}
+
+func TestMetricsFollowNamingConventions(t *testing.T) {
+	problems, err := testutil.GatherAndLint(prometheus.DefaultGatherer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range problems {
+		t.Errorf("'%s': %s", problem.Metric, problem.Text)
+	}
+}
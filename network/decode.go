@@ -0,0 +1,168 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var ErrUnknownMessageType = errors.New("unknown message type")
+var ErrNoPayload = errors.New("message has no payload")
+
+// payloadTypes maps every message type with a payload to the type of its payload.
+var payloadTypes = map[protocol.MessageType]protoreflect.MessageType{
+	protocol.MessageType_HANDSHAKE:                (&protocol.Handshake{}).ProtoReflect().Type(),
+	protocol.MessageType_VOTE_REQUEST_RECEIVED:    (&protocol.VoteRequestReceived{}).ProtoReflect().Type(),
+	protocol.MessageType_VOTE_RECEIVED:            (&protocol.VoteReceived{}).ProtoReflect().Type(),
+	protocol.MessageType_LOG_ENTRY_REPLICATED:     (&protocol.LogEntryReplicated{}).ProtoReflect().Type(),
+	protocol.MessageType_LOG_ENTRY_COMMITTED:      (&protocol.LogEntryCommitted{}).ProtoReflect().Type(),
+	protocol.MessageType_LEADER_SUSPECTED:         (&protocol.LeaderSuspected{}).ProtoReflect().Type(),
+	protocol.MessageType_FOLLOWER_SUSPECTED:       (&protocol.FollowerSuspected{}).ProtoReflect().Type(),
+	protocol.MessageType_PRE_PREPARE_RECEIVED:     (&protocol.PrePrepareReceived{}).ProtoReflect().Type(),
+	protocol.MessageType_PREPARE_RECEIVED:         (&protocol.PrepareReceived{}).ProtoReflect().Type(),
+	protocol.MessageType_COMMIT_RECEIVED:          (&protocol.CommitReceived{}).ProtoReflect().Type(),
+	protocol.MessageType_VIEW_CHANGE_STARTED:      (&protocol.ViewChangeStarted{}).ProtoReflect().Type(),
+	protocol.MessageType_CHECKPOINT_REACHED:       (&protocol.CheckpointReached{}).ProtoReflect().Type(),
+	protocol.MessageType_STATE_TRANSFER_REQUESTED: (&protocol.StateTransferRequested{}).ProtoReflect().Type(),
+}
+
+// TypeMismatchError is returned if the payload is not of the type expected for the message.
+type TypeMismatchError struct {
+	MessageType protocol.MessageType
+	Expected    protoreflect.FullName
+	Actual      protoreflect.FullName
+}
+
+func (err *TypeMismatchError) Error() string {
+	return fmt.Sprintf("'%s' message carries '%s' instead of '%s'", err.MessageType.String(), err.Actual, err.Expected)
+}
+
+// DecodeError is returned if the payload has the expected type, but cannot be unmarshalled.
+type DecodeError struct {
+	MessageType protocol.MessageType
+	Err         error
+}
+
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode payload of '%s' message: %s", err.MessageType.String(), err.Err.Error())
+}
+
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
+// HasPayload reports whether messages of the type carry a payload, e.g. heartbeats do not.
+func HasPayload(messageType protocol.MessageType) bool {
+	_, ok := payloadTypes[messageType]
+	return ok
+}
+
+// NewPayload returns an empty payload of the type carried by messages of the message type.
+func NewPayload(messageType protocol.MessageType) (proto.Message, error) {
+	payloadType, ok := payloadTypes[messageType]
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrUnknownMessageType, messageType)
+	}
+	return payloadType.New().Interface(), nil
+}
+
+// Decode decodes the payload of a message into the concrete type of its message type. Messages without payload
+// decode to nil.
+func Decode(message *protocol.Message) (proto.Message, error) {
+	messageType, ok := payloadTypes[message.MessageType]
+	if !ok {
+		if _, known := protocol.MessageType_name[int32(message.MessageType)]; known {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w %d", ErrUnknownMessageType, message.MessageType)
+	}
+
+	payload := messageType.New().Interface()
+	err := unmarshalPayload(message.MessageType, message.MessageObject, payload)
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// DecodeInto decodes the payload of a message into an existing payload of its message type, e.g. to reuse one
+// payload per message type instead of allocating one per message.
+func DecodeInto(message *protocol.Message, payload proto.Message) error {
+	messageType, ok := payloadTypes[message.MessageType]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownMessageType, message.MessageType)
+	}
+	expected := payload.ProtoReflect().Descriptor().FullName()
+	if messageType.Descriptor().FullName() != expected {
+		return &TypeMismatchError{MessageType: message.MessageType, Expected: expected, Actual: messageType.Descriptor().FullName()}
+	}
+
+	proto.Reset(payload)
+	return unmarshalPayload(message.MessageType, message.MessageObject, payload)
+}
+
+// DecodePayload decodes the payload of a message into T, e.g.
+//
+//	handshake, err := network.DecodePayload[*protocol.Handshake](message)
+func DecodePayload[T proto.Message](message *protocol.Message) (T, error) {
+	var payload T
+	expected := payload.ProtoReflect().Type()
+	if messageType, ok := payloadTypes[message.MessageType]; ok && messageType.Descriptor().FullName() != expected.Descriptor().FullName() {
+		return payload, &TypeMismatchError{MessageType: message.MessageType, Expected: expected.Descriptor().FullName(), Actual: messageType.Descriptor().FullName()}
+	}
+
+	payload = expected.New().Interface().(T)
+	err := unmarshalPayload(message.MessageType, message.MessageObject, payload)
+	return payload, err
+}
+
+// PayloadMismatchError is returned by CastMessage if the Any carries another type. Unlike TypeMismatchError, it
+// names no message type, as the Any is not known to belong to a message.
+type PayloadMismatchError struct {
+	Expected protoreflect.FullName
+	Actual   protoreflect.FullName
+}
+
+func (err *PayloadMismatchError) Error() string {
+	return fmt.Sprintf("payload carries '%s' instead of '%s'", err.Actual, err.Expected)
+}
+
+// CastMessage decodes an Any into T, failing with a PayloadMismatchError if it carries another type.
+func CastMessage[T proto.Message](message *anypb.Any) (T, error) {
+	var payload T
+	payload = payload.ProtoReflect().Type().New().Interface().(T)
+	if message == nil {
+		return payload, ErrNoPayload
+	}
+
+	expected := payload.ProtoReflect().Descriptor().FullName()
+	if actual := message.MessageName(); actual != expected {
+		return payload, &PayloadMismatchError{Expected: expected, Actual: actual}
+	}
+
+	err := proto.Unmarshal(message.Value, payload)
+	if err != nil {
+		return payload, fmt.Errorf("failed to decode '%s' payload: %w", expected, err)
+	}
+	return payload, nil
+}
+
+func unmarshalPayload(messageType protocol.MessageType, messageObject *anypb.Any, payload proto.Message) error {
+	if messageObject == nil {
+		return fmt.Errorf("%w: '%s' message", ErrNoPayload, messageType.String())
+	}
+
+	expected := payload.ProtoReflect().Descriptor().FullName()
+	if actual := messageObject.MessageName(); actual != expected {
+		return &TypeMismatchError{MessageType: messageType, Expected: expected, Actual: actual}
+	}
+
+	err := proto.Unmarshal(messageObject.Value, payload)
+	if err != nil {
+		return &DecodeError{MessageType: messageType, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package network
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var decodeTests = []struct {
+	messageType   protocol.MessageType
+	decodePayload func(message *protocol.Message) (proto.Message, error)
+}{
+	{protocol.MessageType_HANDSHAKE, decodePayloadOf[*protocol.Handshake]},
+	{protocol.MessageType_VOTE_REQUEST_RECEIVED, decodePayloadOf[*protocol.VoteRequestReceived]},
+	{protocol.MessageType_VOTE_RECEIVED, decodePayloadOf[*protocol.VoteReceived]},
+	{protocol.MessageType_LOG_ENTRY_REPLICATED, decodePayloadOf[*protocol.LogEntryReplicated]},
+	{protocol.MessageType_LOG_ENTRY_COMMITTED, decodePayloadOf[*protocol.LogEntryCommitted]},
+	{protocol.MessageType_LEADER_SUSPECTED, decodePayloadOf[*protocol.LeaderSuspected]},
+	{protocol.MessageType_FOLLOWER_SUSPECTED, decodePayloadOf[*protocol.FollowerSuspected]},
+	{protocol.MessageType_PRE_PREPARE_RECEIVED, decodePayloadOf[*protocol.PrePrepareReceived]},
+	{protocol.MessageType_PREPARE_RECEIVED, decodePayloadOf[*protocol.PrepareReceived]},
+	{protocol.MessageType_COMMIT_RECEIVED, decodePayloadOf[*protocol.CommitReceived]},
+	{protocol.MessageType_VIEW_CHANGE_STARTED, decodePayloadOf[*protocol.ViewChangeStarted]},
+	{protocol.MessageType_CHECKPOINT_REACHED, decodePayloadOf[*protocol.CheckpointReached]},
+	{protocol.MessageType_STATE_TRANSFER_REQUESTED, decodePayloadOf[*protocol.StateTransferRequested]},
+}
+
+func decodePayloadOf[T proto.Message](message *protocol.Message) (proto.Message, error) {
+	return DecodePayload[T](message)
+}
+
+// samplePayload returns a payload of the message type with every field set, the values derived from the seed.
+func samplePayload(t testing.TB, messageType protocol.MessageType, seed int) proto.Message {
+	t.Helper()
+	payload, err := NewPayload(messageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reflected := payload.ProtoReflect()
+	fields := reflected.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		value := seed*10 + i + 1
+		if field.IsList() {
+			list := reflected.Mutable(field).List()
+			list.Append(protoreflect.ValueOfEnum(protoreflect.EnumNumber(value%4 + 1)))
+			list.Append(protoreflect.ValueOfEnum(protoreflect.EnumNumber((value+1)%4 + 1)))
+			continue
+		}
+
+		switch field.Kind() {
+		case protoreflect.BoolKind:
+			reflected.Set(field, protoreflect.ValueOfBool(seed%2 == 0))
+		case protoreflect.Uint32Kind:
+			reflected.Set(field, protoreflect.ValueOfUint32(uint32(value)))
+		case protoreflect.Uint64Kind:
+			reflected.Set(field, protoreflect.ValueOfUint64(uint64(value)<<33))
+		case protoreflect.Int64Kind:
+			reflected.Set(field, protoreflect.ValueOfInt64(-int64(value)))
+		case protoreflect.StringKind:
+			reflected.Set(field, protoreflect.ValueOfString(strings.Repeat("x", value)))
+		default:
+			t.Fatalf("no sample for field '%s' of kind %s", field.FullName(), field.Kind())
+		}
+	}
+	return payload
+}
+
+func sampleMessage(t testing.TB, messageType protocol.MessageType, payload proto.Message) *protocol.Message {
+	t.Helper()
+	messageObject, err := anypb.New(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &protocol.Message{MessageType: messageType, MessageObject: messageObject}
+}
+
+// otherMessageType returns a message type whose payload differs from the one of the message type.
+func otherMessageType(messageType protocol.MessageType) protocol.MessageType {
+	if messageType == protocol.MessageType_HANDSHAKE {
+		return protocol.MessageType_VOTE_RECEIVED
+	}
+	return protocol.MessageType_HANDSHAKE
+}
+
+func TestDecodeTestsCoverPayloadTypes(t *testing.T) {
+	covered := make(map[protocol.MessageType]bool)
+	for _, test := range decodeTests {
+		covered[test.messageType] = true
+	}
+	for messageType := range payloadTypes {
+		if !covered[messageType] {
+			t.Errorf("no decode test for '%s'", messageType.String())
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	for _, test := range decodeTests {
+		t.Run(test.messageType.String(), func(t *testing.T) {
+			expected := samplePayload(t, test.messageType, 1)
+			message := sampleMessage(t, test.messageType, expected)
+
+			decoded, err := Decode(message)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !proto.Equal(decoded, expected) {
+				t.Errorf("Decode: got %v, want %v", decoded, expected)
+			}
+
+			reused := samplePayload(t, test.messageType, 2)
+			err = DecodeInto(message, reused)
+			if err != nil {
+				t.Fatalf("DecodeInto: %v", err)
+			}
+			if !proto.Equal(reused, expected) {
+				t.Errorf("DecodeInto: got %v, want %v", reused, expected)
+			}
+
+			decoded, err = test.decodePayload(message)
+			if err != nil {
+				t.Fatalf("DecodePayload: %v", err)
+			}
+			if !proto.Equal(decoded, expected) {
+				t.Errorf("DecodePayload: got %v, want %v", decoded, expected)
+			}
+		})
+	}
+}
+
+func TestDecodeTypeMismatch(t *testing.T) {
+	for _, test := range decodeTests {
+		t.Run(test.messageType.String(), func(t *testing.T) {
+			other := otherMessageType(test.messageType)
+			message := sampleMessage(t, test.messageType, samplePayload(t, other, 1))
+			expected := payloadTypes[test.messageType].Descriptor().FullName()
+			actual := payloadTypes[other].Descriptor().FullName()
+
+			payload, err := NewPayload(test.messageType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, decodeErr := Decode(message)
+			_, decodePayloadErr := test.decodePayload(message)
+			for name, err := range map[string]error{
+				"Decode":        decodeErr,
+				"DecodeInto":    DecodeInto(message, payload),
+				"DecodePayload": decodePayloadErr,
+			} {
+				var mismatch *TypeMismatchError
+				if !errors.As(err, &mismatch) {
+					t.Errorf("%s: got %v, want a TypeMismatchError", name, err)
+					continue
+				}
+				if mismatch.MessageType != test.messageType || mismatch.Expected != expected || mismatch.Actual != actual {
+					t.Errorf("%s: got %+v, want '%s' carrying '%s' instead of '%s'", name, mismatch, test.messageType.String(), actual, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodePayloadOfWrongType(t *testing.T) {
+	message := sampleMessage(t, protocol.MessageType_VOTE_RECEIVED, samplePayload(t, protocol.MessageType_VOTE_RECEIVED, 1))
+	_, err := DecodePayload[*protocol.Handshake](message)
+	var mismatch *TypeMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("got %v, want a TypeMismatchError", err)
+	}
+	expected := (&protocol.Handshake{}).ProtoReflect().Descriptor().FullName()
+	actual := (&protocol.VoteReceived{}).ProtoReflect().Descriptor().FullName()
+	if mismatch.Expected != expected || mismatch.Actual != actual {
+		t.Errorf("got '%s' instead of '%s', want '%s' instead of '%s'", mismatch.Actual, mismatch.Expected, actual, expected)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	for _, test := range decodeTests {
+		t.Run(test.messageType.String(), func(t *testing.T) {
+			typeUrl := typeUrlPrefix + string(payloadTypes[test.messageType].Descriptor().FullName())
+			message := &protocol.Message{MessageType: test.messageType, MessageObject: &anypb.Any{TypeUrl: typeUrl, Value: []byte{0xff}}}
+
+			payload, err := NewPayload(test.messageType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, decodeErr := Decode(message)
+			_, decodePayloadErr := test.decodePayload(message)
+			for name, err := range map[string]error{
+				"Decode":        decodeErr,
+				"DecodeInto":    DecodeInto(message, payload),
+				"DecodePayload": decodePayloadErr,
+			} {
+				var decodeError *DecodeError
+				if !errors.As(err, &decodeError) {
+					t.Errorf("%s: got %v, want a DecodeError", name, err)
+					continue
+				}
+				if decodeError.MessageType != test.messageType || decodeError.Unwrap() == nil {
+					t.Errorf("%s: got %+v, want a wrapped error of '%s'", name, decodeError, test.messageType.String())
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWithoutPayload(t *testing.T) {
+	decoded, err := Decode(&protocol.Message{MessageType: protocol.MessageType_HEARTBEAT})
+	if decoded != nil || err != nil {
+		t.Errorf("heartbeat: got %v, %v, want no payload", decoded, err)
+	}
+
+	_, err = Decode(&protocol.Message{MessageType: protocol.MessageType_VOTE_RECEIVED})
+	if !errors.Is(err, ErrNoPayload) {
+		t.Errorf("missing payload: got %v, want ErrNoPayload", err)
+	}
+
+	_, err = Decode(&protocol.Message{MessageType: protocol.MessageType(1000)})
+	if !errors.Is(err, ErrUnknownMessageType) {
+		t.Errorf("unknown message type: got %v, want ErrUnknownMessageType", err)
+	}
+}
+
+func TestCastMessage(t *testing.T) {
+	expected := samplePayload(t, protocol.MessageType_VOTE_RECEIVED, 1)
+	messageObject, err := anypb.New(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cast, err := CastMessage[*protocol.VoteReceived](messageObject)
+	if err != nil || !proto.Equal(cast, expected) {
+		t.Errorf("got %v, %v, want %v", cast, err, expected)
+	}
+
+	_, err = CastMessage[*protocol.Handshake](messageObject)
+	var mismatch *PayloadMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("got %v, want a PayloadMismatchError", err)
+	}
+	if strings.Contains(err.Error(), protocol.MessageType_DA_RESPONSE.String()) {
+		t.Errorf("error '%s' names a message type", err.Error())
+	}
+
+	_, err = CastMessage[*protocol.Handshake](nil)
+	if !errors.Is(err, ErrNoPayload) {
+		t.Errorf("nil payload: got %v, want ErrNoPayload", err)
+	}
+}
@@ -0,0 +1,177 @@
+package network
+
+import (
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const typeUrlPrefix = "type.googleapis.com/"
+
+// typeUrls interns the type URLs of the core payloads, so decoding them does not allocate a string per message.
+var typeUrls = make(map[string]string, len(payloadTypes))
+
+func init() {
+	for _, payloadType := range payloadTypes {
+		typeUrl := typeUrlPrefix + string(payloadType.Descriptor().FullName())
+		typeUrls[typeUrl] = typeUrl
+	}
+}
+
+const (
+	// poisonMessageType is 0xDEADBEEF, the message type and action type of freed messages in poison mode.
+	poisonMessageType = protocol.MessageType(-559038737)
+	poisonTypeUrl     = typeUrlPrefix + "poisoned.FreedMessage"
+	poisonByte        = 0xDE
+)
+
+// poisonFreed makes freed messages unusable, see SetPoisonFreedMessages.
+var poisonFreed atomic.Bool
+
+// SetPoisonFreedMessages enables the poison mode to catch messages used after they were freed, e.g. while
+// developing a new action. In poison mode, freed messages are overwritten with garbage and never reused, and misuse
+// panics instead of being logged. It costs an allocation per message and must not be enabled in experiments.
+func SetPoisonFreedMessages(poison bool) {
+	poisonFreed.Store(poison)
+}
+
+// envelope is a pooled message together with its response and the storage of its payload. Once the pool is warm,
+// reading a message of the core protocol and responding to it allocates nothing.
+type envelope struct {
+	message  protocol.Message
+	response protocol.Message
+	payload  anypb.Any
+	// generation is incremented whenever the envelope is freed. A message holds the generation it was handed out
+	// with, so it is detected when it is used after it was freed, even if the envelope was reused by then.
+	generation atomic.Uint64
+	responded  atomic.Bool
+	// sequence numbers the message on its connection.
+	sequence uint64
+}
+
+// lease hands out the envelope for a new message and returns the generation of the message.
+func (envelope *envelope) lease() uint64 {
+	envelope.responded.Store(false)
+	return envelope.generation.Load()
+}
+
+// valid reports whether the message of the generation is not freed yet.
+func (envelope *envelope) valid(generation uint64) bool {
+	return envelope.generation.Load() == generation
+}
+
+// free ends the message of the generation. It returns false if the message was already freed.
+func (envelope *envelope) free(generation uint64) bool {
+	return envelope.generation.CompareAndSwap(generation, generation+1)
+}
+
+// poison overwrites the message and its response, so whoever still reads them after they were freed gets an
+// unknown message type and an undecodable payload instead of the next message. The payload of the response may be
+// shared, e.g. by all noop responses, so it is only detached.
+func (envelope *envelope) poison() {
+	envelope.message.Reset()
+	envelope.message.MessageType = poisonMessageType
+	envelope.message.ActionType = protocol.ActionType(poisonMessageType)
+	envelope.payload.TypeUrl = poisonTypeUrl
+	for i := range envelope.payload.Value {
+		envelope.payload.Value[i] = poisonByte
+	}
+	envelope.message.MessageObject = &envelope.payload
+
+	envelope.response.Reset()
+	envelope.response.MessageType = poisonMessageType
+	envelope.response.ActionType = protocol.ActionType(poisonMessageType)
+}
+
+// unmarshal decodes the message, reusing the storage of the payload. Messages with custom data, unknown fields or
+// repeated fields are left to proto.Unmarshal.
+func (envelope *envelope) unmarshal(messageBytes []byte) error {
+	message := &envelope.message
+	message.Reset()
+	envelope.payload.TypeUrl = ""
+	envelope.payload.Value = envelope.payload.Value[:0]
+
+	remaining := messageBytes
+	for len(remaining) > 0 {
+		number, wireType, n := protowire.ConsumeTag(remaining)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		remaining = remaining[n:]
+
+		switch {
+		case number == 1 && wireType == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(remaining)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			message.MessageType = protocol.MessageType(value)
+			remaining = remaining[n:]
+		case number == 2 && wireType == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(remaining)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			message.ActionType = protocol.ActionType(value)
+			remaining = remaining[n:]
+		case number == 3 && wireType == protowire.BytesType && message.MessageObject == nil:
+			value, n := protowire.ConsumeBytes(remaining)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			if !envelope.unmarshalPayload(value) {
+				return proto.Unmarshal(messageBytes, message)
+			}
+			message.MessageObject = &envelope.payload
+			remaining = remaining[n:]
+		default:
+			return proto.Unmarshal(messageBytes, message)
+		}
+	}
+	return nil
+}
+
+// unmarshalPayload decodes the Any of a message into the payload storage. It returns false if the Any has unknown
+// or repeated fields.
+func (envelope *envelope) unmarshalPayload(payloadBytes []byte) bool {
+	payload := &envelope.payload
+	seenTypeUrl, seenValue := false, false
+	for len(payloadBytes) > 0 {
+		number, wireType, n := protowire.ConsumeTag(payloadBytes)
+		if n < 0 {
+			return false
+		}
+		payloadBytes = payloadBytes[n:]
+		if wireType != protowire.BytesType {
+			return false
+		}
+
+		value, n := protowire.ConsumeBytes(payloadBytes)
+		if n < 0 {
+			return false
+		}
+		payloadBytes = payloadBytes[n:]
+
+		switch {
+		case number == 1 && !seenTypeUrl:
+			seenTypeUrl = true
+			typeUrl, ok := typeUrls[string(value)]
+			if !ok {
+				if !utf8.Valid(value) {
+					return false
+				}
+				typeUrl = string(value)
+			}
+			payload.TypeUrl = typeUrl
+		case number == 2 && !seenValue:
+			seenValue = true
+			payload.Value = append(payload.Value, value...)
+		default:
+			return false
+		}
+	}
+	return true
+}
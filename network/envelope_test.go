@@ -0,0 +1,137 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type unmarshalTest struct {
+	name  string
+	bytes []byte
+	// fallback is set if the message must be left to proto.Unmarshal.
+	fallback bool
+}
+
+func marshalAny(t *testing.T, payload *anypb.Any) []byte {
+	t.Helper()
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return payloadBytes
+}
+
+// withPayloadBytes appends the bytes as payload field of a message.
+func withPayloadBytes(messageBytes []byte, payloadBytes []byte) []byte {
+	messageBytes = protowire.AppendTag(messageBytes, 3, protowire.BytesType)
+	return protowire.AppendBytes(messageBytes, payloadBytes)
+}
+
+func unmarshalTests(t *testing.T) []unmarshalTest {
+	t.Helper()
+	var tests []unmarshalTest
+	for i, test := range decodeTests {
+		message := sampleMessage(t, test.messageType, samplePayload(t, test.messageType, i))
+		message.ActionType = protocol.ActionType(i % 5)
+		tests = append(tests, unmarshalTest{name: test.messageType.String(), bytes: marshal(t, message)})
+	}
+
+	vote := sampleMessage(t, protocol.MessageType_VOTE_RECEIVED, samplePayload(t, protocol.MessageType_VOTE_RECEIVED, 1))
+	voteAny := marshalAny(t, vote.MessageObject)
+	typeUrl := protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), vote.MessageObject.TypeUrl)
+	value := protowire.AppendBytes(protowire.AppendTag(nil, 2, protowire.BytesType), vote.MessageObject.Value)
+	unknownField := protowire.AppendVarint(protowire.AppendTag(nil, 99, protowire.VarintType), 7)
+	header := protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), uint64(protocol.MessageType_VOTE_RECEIVED))
+
+	customData := &protocol.Message{
+		MessageType: protocol.MessageType_VOTE_RECEIVED,
+		CustomData:  &protocol.CustomData{ProtocolName: "test", Data: vote.MessageObject},
+	}
+	otherType := &protocol.Message{MessageType: protocol.MessageType_VOTE_RECEIVED, MessageObject: &anypb.Any{TypeUrl: typeUrlPrefix + "other.Event", Value: []byte{8, 1}}}
+
+	return append(tests,
+		unmarshalTest{name: "empty", bytes: []byte{}},
+		unmarshalTest{name: "without payload", bytes: marshal(t, &protocol.Message{MessageType: protocol.MessageType_HEARTBEAT})},
+		unmarshalTest{name: "empty payload", bytes: marshal(t, &protocol.Message{MessageType: protocol.MessageType_DA_RESPONSE, MessageObject: &anypb.Any{}})},
+		unmarshalTest{name: "uninterned type url", bytes: marshal(t, otherType)},
+		unmarshalTest{name: "payload before type", bytes: append(withPayloadBytes(nil, voteAny), header...)},
+		unmarshalTest{name: "value before type url", bytes: withPayloadBytes(header, append(append([]byte{}, value...), typeUrl...))},
+		unmarshalTest{name: "custom data", bytes: marshal(t, customData), fallback: true},
+		unmarshalTest{name: "unknown field", bytes: append(marshal(t, vote), unknownField...), fallback: true},
+		unmarshalTest{name: "unknown field of payload", bytes: withPayloadBytes(header, append(append([]byte{}, voteAny...), unknownField...)), fallback: true},
+		unmarshalTest{name: "repeated payload", bytes: withPayloadBytes(marshal(t, vote), voteAny), fallback: true},
+		unmarshalTest{name: "repeated type url", bytes: withPayloadBytes(header, append(append([]byte{}, voteAny...), typeUrl...)), fallback: true},
+	)
+}
+
+// TestEnvelopeUnmarshal compares the envelope with proto.Unmarshal. All messages are read into the same envelope,
+// so every message is also read into the storage of the one before.
+func TestEnvelopeUnmarshal(t *testing.T) {
+	envelope := &envelope{}
+	for _, test := range unmarshalTests(t) {
+		expected := &protocol.Message{}
+		err := proto.Unmarshal(test.bytes, expected)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		err = envelope.unmarshal(test.bytes)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if !proto.Equal(&envelope.message, expected) {
+			t.Errorf("%s: got %v, want %v", test.name, &envelope.message, expected)
+		}
+		// Only proto.Unmarshal reads custom data or allocates a payload of its own.
+		fastPath := envelope.message.CustomData == nil &&
+			(envelope.message.MessageObject == nil || envelope.message.MessageObject == &envelope.payload)
+		if fastPath == test.fallback {
+			t.Errorf("%s: fell back to proto.Unmarshal %t, want %t", test.name, !fastPath, test.fallback)
+		}
+	}
+}
+
+func TestEnvelopeUnmarshalError(t *testing.T) {
+	vote := marshal(t, sampleMessage(t, protocol.MessageType_VOTE_RECEIVED, samplePayload(t, protocol.MessageType_VOTE_RECEIVED, 1)))
+	header := protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), uint64(protocol.MessageType_VOTE_RECEIVED))
+	invalidTypeUrl := protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), []byte{0xff, 0xfe})
+
+	for _, test := range []struct {
+		name  string
+		bytes []byte
+	}{
+		{"truncated", vote[:len(vote)-1]},
+		{"truncated tag", []byte{0x80}},
+		{"truncated varint", []byte{0x08, 0x80}},
+		{"invalid utf-8 type url", withPayloadBytes(header, invalidTypeUrl)},
+	} {
+		if err := proto.Unmarshal(test.bytes, &protocol.Message{}); err == nil {
+			t.Fatalf("%s: proto.Unmarshal read the invalid message", test.name)
+		}
+		if err := (&envelope{}).unmarshal(test.bytes); err == nil {
+			t.Errorf("%s: read the invalid message", test.name)
+		}
+	}
+}
+
+func TestEnvelopeUnmarshalDoesNotAllocate(t *testing.T) {
+	envelope := &envelope{}
+	tests := unmarshalTests(t)[:len(decodeTests)]
+	for _, test := range tests {
+		_ = envelope.unmarshal(test.bytes)
+	}
+
+	for _, test := range tests {
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = envelope.unmarshal(test.bytes)
+		})
+		if allocs != 0 {
+			t.Errorf("%s: %v allocations per message, want 0", test.name, allocs)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package network
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/sys/unix"
+	"io"
+	"net"
+	"syscall"
+)
+
+const (
+	// SocketTypeStream is a SOCK_STREAM unix socket. Messages are framed with a uvarint length prefix.
+	SocketTypeStream = "unix"
+	// SocketTypePacket is a SOCK_SEQPACKET unix socket. Every packet contains exactly one message.
+	SocketTypePacket = "unixpacket"
+)
+
+var ErrMessageTooLarge = errors.New("message exceeds maximum message size")
+
+// maxDiscardFactor limits the oversized frames that are skipped to this multiple of the maximum message size.
+// Larger frames close the connection instead of reading them to the end, they are most likely corrupted length
+// prefixes.
+const maxDiscardFactor = 4
+
+// MessageTooLargeError is returned for messages exceeding the maximum message size.
+// The connection stays usable, only the message is dropped.
+type MessageTooLargeError struct {
+	// Size is the size of the dropped message or 0 if the size is unknown.
+	Size    int
+	MaxSize int
+}
+
+func (err *MessageTooLargeError) Error() string {
+	if err.Size == 0 {
+		return fmt.Sprintf("message exceeds maximum message size of %d bytes", err.MaxSize)
+	}
+	return fmt.Sprintf("message of %d bytes exceeds maximum message size of %d bytes", err.Size, err.MaxSize)
+}
+
+func (err *MessageTooLargeError) Is(target error) bool {
+	return target == ErrMessageTooLarge
+}
+
+// streamConn frames messages on a stream connection, i.e. a unix stream socket or TCP,
+// with a uvarint length prefix.
+type streamConn struct {
+	net.Conn
+	reader *bufio.Reader
+	// writeBuffer is reused to prefix every message with its length.
+	writeBuffer []byte
+}
+
+func newStreamConn(conn net.Conn) *streamConn {
+	return &streamConn{Conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// ReadMessage reads the length prefix and blocks until the whole message is read, regardless of how the bytes
+// are split or coalesced by the socket. Messages larger than the buffer are skipped, frames far above it are an
+// error, see maxDiscardFactor.
+func (conn *streamConn) ReadMessage(buffer []byte) ([]byte, error) {
+	size, err := binary.ReadUvarint(conn.reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > maxDiscardFactor*uint64(len(buffer)) {
+		return nil, fmt.Errorf("invalid frame length %d, far above the maximum message size of %d bytes", size, len(buffer))
+	}
+
+	if size > uint64(len(buffer)) {
+		_, err = conn.reader.Discard(int(size))
+		if err != nil {
+			return nil, err
+		}
+		return nil, &MessageTooLargeError{Size: int(size), MaxSize: len(buffer)}
+	}
+
+	_, err = io.ReadFull(conn.reader, buffer[:size])
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return buffer[:size], nil
+}
+
+func (conn *streamConn) WriteMessage(message []byte) error {
+	conn.writeBuffer = binary.AppendUvarint(conn.writeBuffer[:0], uint64(len(message)))
+	conn.writeBuffer = append(conn.writeBuffer, message...)
+	_, err := conn.Write(conn.writeBuffer)
+	return err
+}
+
+// packetConn is a SOCK_SEQPACKET unix socket, every packet contains exactly one message without prefix.
+type packetConn struct {
+	*net.UnixConn
+	raw syscall.RawConn
+}
+
+func newPacketConn(conn *net.UnixConn) (*packetConn, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	return &packetConn{UnixConn: conn, raw: raw}, nil
+}
+
+// ReadMessage reads the next packet. It calls recvmsg itself, because the net package reports every empty read of
+// a SOCK_SEQPACKET socket as io.EOF, but an empty packet is a valid empty message. An empty read is only the end
+// of the connection if the peer hung up. An empty packet sent right before hanging up is lost, which does not
+// matter, its response could not be delivered anyway.
+func (conn *packetConn) ReadMessage(buffer []byte) ([]byte, error) {
+	var bytesRead, flags int
+	var readErr error
+	err := conn.raw.Read(func(fd uintptr) bool {
+		bytesRead, _, flags, _, readErr = unix.Recvmsg(int(fd), buffer, nil, 0)
+		return !errors.Is(readErr, unix.EAGAIN)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, &net.OpError{Op: "read", Net: SocketTypePacket, Addr: conn.LocalAddr(), Err: readErr}
+	}
+	if flags&unix.MSG_TRUNC != 0 {
+		return nil, &MessageTooLargeError{MaxSize: len(buffer)}
+	}
+	if bytesRead == 0 && conn.hungUp() {
+		return nil, io.EOF
+	}
+
+	return buffer[:bytesRead], nil
+}
+
+// hungUp returns whether the peer closed the connection.
+func (conn *packetConn) hungUp() bool {
+	hungUp := false
+	_ = conn.raw.Control(func(fd uintptr) {
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		ready, err := unix.Poll(fds, 0)
+		hungUp = err == nil && ready > 0 && fds[0].Revents&unix.POLLHUP != 0
+	})
+	return hungUp
+}
+
+func (conn *packetConn) WriteMessage(message []byte) error {
+	_, err := conn.Write(message)
+	return err
+}
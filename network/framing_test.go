@@ -0,0 +1,320 @@
+package network
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+)
+
+// pipeStreamConn returns the DA side of a stream connection and the node side to write to.
+func pipeStreamConn(t *testing.T) (*streamConn, net.Conn) {
+	t.Helper()
+	da, node := net.Pipe()
+	t.Cleanup(func() {
+		_ = da.Close()
+		_ = node.Close()
+	})
+	return newStreamConn(da), node
+}
+
+// writeAsync writes the chunks one after another, pausing in between so the reader sees them separately.
+func writeAsync(t *testing.T, conn net.Conn, pause time.Duration, chunks ...[]byte) {
+	t.Helper()
+	go func() {
+		for _, chunk := range chunks {
+			_, err := conn.Write(chunk)
+			if err != nil {
+				return
+			}
+			time.Sleep(pause)
+		}
+	}()
+}
+
+func readTerms(t *testing.T, conn Conn, buffer []byte, count int) []uint64 {
+	t.Helper()
+	terms := make([]uint64, 0, count)
+	for i := 0; i < count; i++ {
+		messageBytes, err := conn.ReadMessage(buffer)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		envelope := &envelope{}
+		err = envelope.unmarshal(messageBytes)
+		if err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		terms = append(terms, responseTerm(t, &envelope.message))
+	}
+	return terms
+}
+
+func expectTerms(t *testing.T, terms []uint64, expected ...uint64) {
+	t.Helper()
+	if len(terms) != len(expected) {
+		t.Fatalf("got terms %v, want %v", terms, expected)
+	}
+	for i := range terms {
+		if terms[i] != expected[i] {
+			t.Fatalf("got terms %v, want %v", terms, expected)
+		}
+	}
+}
+
+func TestStreamConnCoalescedFrames(t *testing.T) {
+	conn, node := pipeStreamConn(t)
+	coalesced := append(append(frame(t, voteMessage(t, 1)), frame(t, voteMessage(t, 2))...), frame(t, voteMessage(t, 3))...)
+	writeAsync(t, node, 0, coalesced)
+
+	expectTerms(t, readTerms(t, conn, make([]byte, testMaxMessageSize), 3), 1, 2, 3)
+}
+
+func TestStreamConnSplitFrames(t *testing.T) {
+	conn, node := pipeStreamConn(t)
+	framed := append(frame(t, voteMessage(t, 1)), frame(t, voteMessage(t, 2))...)
+	chunks := make([][]byte, 0, len(framed))
+	for i := range framed {
+		chunks = append(chunks, framed[i:i+1])
+	}
+	writeAsync(t, node, time.Millisecond, chunks...)
+
+	expectTerms(t, readTerms(t, conn, make([]byte, testMaxMessageSize), 2), 1, 2)
+}
+
+func TestStreamConnPartialReads(t *testing.T) {
+	conn, node := pipeStreamConn(t)
+	first, second := frame(t, voteMessage(t, 1)), frame(t, voteMessage(t, 2))
+	// The length prefix arrives alone, then the first message together with half of the second.
+	writeAsync(t, node, 5*time.Millisecond, first[:1], append(first[1:], second[:len(second)/2]...), second[len(second)/2:])
+
+	expectTerms(t, readTerms(t, conn, make([]byte, testMaxMessageSize), 2), 1, 2)
+}
+
+// TestStreamConnReusesBuffer reads a short message into the buffer still holding a longer one. Only the short
+// message may be returned.
+func TestStreamConnReusesBuffer(t *testing.T) {
+	conn, node := pipeStreamConn(t)
+	long, short := voteMessage(t, 1<<60), voteMessage(t, 2)
+	long.ActionType = protocol.ActionType_STOP_ACTION_TYPE
+	writeAsync(t, node, 0, frame(t, long), frame(t, short))
+
+	buffer := make([]byte, testMaxMessageSize)
+	longBytes, err := conn.ReadMessage(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortBytes, err := conn.ReadMessage(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shortBytes) >= len(longBytes) || !bytes.Equal(shortBytes, marshal(t, short)) {
+		t.Errorf("got %x, want %x", shortBytes, marshal(t, short))
+	}
+	if &shortBytes[0] != &buffer[0] {
+		t.Error("message was not read into the buffer")
+	}
+}
+
+func TestStreamConnDropsOversizedFrame(t *testing.T) {
+	conn, node := pipeStreamConn(t)
+	oversized := append(binary.AppendUvarint(nil, 2*testMaxMessageSize), make([]byte, 2*testMaxMessageSize)...)
+	writeAsync(t, node, 0, oversized, frame(t, voteMessage(t, 2)))
+
+	buffer := make([]byte, testMaxMessageSize)
+	_, err := conn.ReadMessage(buffer)
+	var tooLarge *MessageTooLargeError
+	if !errors.As(err, &tooLarge) || !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("got %v, want a MessageTooLargeError", err)
+	}
+	if tooLarge.Size != 2*testMaxMessageSize || tooLarge.MaxSize != testMaxMessageSize {
+		t.Errorf("got %+v, want size %d of %d", tooLarge, 2*testMaxMessageSize, testMaxMessageSize)
+	}
+
+	expectTerms(t, readTerms(t, conn, buffer, 1), 2)
+}
+
+// TestStreamConnClosesOnFarOversizedFrame sends only the length prefix of a frame far above the maximum message
+// size. It must fail at once instead of waiting to discard the frame.
+func TestStreamConnClosesOnFarOversizedFrame(t *testing.T) {
+	conn, node := pipeStreamConn(t)
+	writeAsync(t, node, 0, binary.AppendUvarint(nil, maxDiscardFactor*testMaxMessageSize+1))
+
+	err := conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.ReadMessage(make([]byte, testMaxMessageSize))
+	if err == nil || errors.Is(err, ErrMessageTooLarge) || errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("got %v, want an invalid frame length", err)
+	}
+}
+
+// packetConnPair returns the DA side of a packet connection and the node side to write to.
+func packetConnPair(t *testing.T) (*packetConn, net.Conn) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "packet.sock")
+	listener, err := net.ListenUnix(SocketTypePacket, &net.UnixAddr{Name: path, Net: SocketTypePacket})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	node, err := net.Dial(SocketTypePacket, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = node.Close() })
+	da, err := listener.AcceptUnix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := newPacketConn(da)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, node
+}
+
+// TestPacketConnTruncated sends a packet larger than the buffer. The kernel truncates it with MSG_TRUNC, it must be
+// dropped and the next packet read as usual.
+func TestPacketConnTruncated(t *testing.T) {
+	conn, node := packetConnPair(t)
+	_, err := node.Write(make([]byte, 2*testMaxMessageSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = node.Write(marshal(t, voteMessage(t, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := make([]byte, testMaxMessageSize)
+	_, err = conn.ReadMessage(buffer)
+	if !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("got %v, want ErrMessageTooLarge", err)
+	}
+	expectTerms(t, readTerms(t, conn, buffer, 1), 2)
+}
+
+// TestPacketConnEmptyMessage sends an empty packet, which is an empty message and not the end of the connection.
+func TestPacketConnEmptyMessage(t *testing.T) {
+	conn, node := packetConnPair(t)
+	_, err := node.Write(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = node.Write(marshal(t, voteMessage(t, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := make([]byte, testMaxMessageSize)
+	messageBytes, err := conn.ReadMessage(buffer)
+	if err != nil || len(messageBytes) != 0 {
+		t.Fatalf("got %x and %v, want an empty message", messageBytes, err)
+	}
+	expectTerms(t, readTerms(t, conn, buffer, 1), 2)
+
+	_ = node.Close()
+	_, err = conn.ReadMessage(buffer)
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("got %v after the node closed the connection, want EOF", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, transport := range testTransports(t) {
+		t.Run(transport.name, func(t *testing.T) {
+			startNetworkLayer(t, transport.transport)
+			node := connect(t, transport)
+			node.handshake(1)
+
+			for term := uint64(1); term <= 10; term++ {
+				node.mustSend(voteMessage(t, term))
+			}
+			terms := make([]uint64, 0, 10)
+			for i := 0; i < 10; i++ {
+				terms = append(terms, responseTerm(t, node.mustReceive()))
+			}
+			expectTerms(t, terms, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+		})
+	}
+}
+
+// oversizedMessage exceeds the maximum message size of the tests, but not the receive limit of gRPC.
+func oversizedMessage(t *testing.T) *protocol.Message {
+	t.Helper()
+	message := voteMessage(t, 99)
+	message.MessageObject.Value = append(message.MessageObject.Value, make([]byte, 2*testMaxMessageSize)...)
+	return message
+}
+
+func TestOversizedMessageIsAnsweredByDefault(t *testing.T) {
+	for _, transport := range testTransports(t) {
+		t.Run(transport.name, func(t *testing.T) {
+			startNetworkLayer(t, transport.transport)
+			node := connect(t, transport)
+			node.handshake(1)
+
+			node.mustSend(oversizedMessage(t))
+			node.mustSend(voteMessage(t, 2))
+			response := node.mustReceive()
+			if response.MessageType != protocol.MessageType_DA_RESPONSE || len(response.MessageObject.GetTypeUrl()) != 0 {
+				t.Errorf("got %v, want an empty DA response", response)
+			}
+			expectTerms(t, []uint64{responseTerm(t, node.mustReceive())}, 2)
+		})
+	}
+}
+
+// TestDefaultResponseStaysInOrder pipelines messages while the processor is slow. The default responses of the
+// oversized and the undecodable message must be written after the responses of the messages before them.
+func TestDefaultResponseStaysInOrder(t *testing.T) {
+	for _, transport := range testTransports(t) {
+		t.Run(transport.name, func(t *testing.T) {
+			startSlowNetworkLayer(t, transport.transport, 50*time.Millisecond)
+			node := connect(t, transport)
+			node.handshake(1)
+
+			node.mustSend(voteMessage(t, 1))
+			node.mustSend(voteMessage(t, 2))
+			node.mustSend(oversizedMessage(t))
+			node.mustSend(voteMessage(t, 4))
+			if transport.name != "grpc" {
+				// gRPC cannot send bytes that are no message.
+				err := node.write(frameOrPacket(transport.name, []byte{0xff, 0xff}))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			node.mustSend(voteMessage(t, 6))
+
+			expected := []uint64{1, 2, 0, 4, 0, 6}
+			if transport.name == "grpc" {
+				expected = []uint64{1, 2, 0, 4, 6}
+			}
+			terms := make([]uint64, 0, len(expected))
+			for range expected {
+				terms = append(terms, responseTerm(t, node.mustReceive()))
+			}
+			expectTerms(t, terms, expected...)
+		})
+	}
+}
+
+// frameOrPacket prefixes the bytes with their length on stream sockets.
+func frameOrPacket(transport string, data []byte) []byte {
+	if transport == "unixpacket" {
+		return data
+	}
+	return append(binary.AppendUvarint(nil, uint64(len(data))), data...)
+}
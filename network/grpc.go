@@ -0,0 +1,172 @@
+package network
+
+import (
+	"errors"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/protobuf/proto"
+	"net"
+	"sync"
+)
+
+// defaultGrpcMaxReceiveSize is the receive limit of gRPC. Larger messages fail the stream, messages between the
+// maximum message size and this limit are dropped like on the other transports.
+const defaultGrpcMaxReceiveSize = 4 * 1024 * 1024
+
+type grpcTransport struct {
+	address        string
+	maxReceiveSize int
+}
+
+// NewGrpcTransport serves the DistributedAssistant gRPC service. Every stream opened by a node is a connection.
+func NewGrpcTransport(address string, maxMessageSize int) Transport {
+	return &grpcTransport{address: address, maxReceiveSize: util.Max(maxMessageSize, defaultGrpcMaxReceiveSize)}
+}
+
+func (transport *grpcTransport) Address() string {
+	return "grpc://" + transport.address
+}
+
+func (transport *grpcTransport) Listen() (Listener, error) {
+	tcpListener, err := net.Listen("tcp", transport.address)
+	if err != nil {
+		return nil, err
+	}
+
+	listener := &grpcListener{
+		server: grpc.NewServer(grpc.ForceServerCodec(frameCodec{}), grpc.MaxRecvMsgSize(transport.maxReceiveSize)),
+		conns:  make(chan *grpcConn),
+		failed: make(chan error, 1),
+		closed: make(chan struct{}),
+	}
+	protocol.RegisterDistributedAssistantServer(listener.server, listener)
+	go func() {
+		listener.failed <- listener.server.Serve(tcpListener)
+	}()
+
+	return listener, nil
+}
+
+type grpcListener struct {
+	protocol.UnimplementedDistributedAssistantServer
+	server    *grpc.Server
+	conns     chan *grpcConn
+	failed    chan error
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Connect hands the stream to Accept and serves it until the connection is closed.
+func (listener *grpcListener) Connect(stream protocol.DistributedAssistant_ConnectServer) error {
+	conn := &grpcConn{stream: stream, closed: make(chan struct{})}
+	select {
+	case listener.conns <- conn:
+	case <-listener.closed:
+		return errors.New("DA is shutting down")
+	}
+
+	select {
+	case <-conn.closed:
+	case <-stream.Context().Done():
+	}
+	return nil
+}
+
+func (listener *grpcListener) Accept() (Conn, error) {
+	select {
+	case conn := <-listener.conns:
+		return conn, nil
+	case err := <-listener.failed:
+		if err == nil {
+			err = net.ErrClosed
+		}
+		return nil, err
+	case <-listener.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (listener *grpcListener) Close() error {
+	listener.closeOnce.Do(func() {
+		close(listener.closed)
+		listener.server.Stop()
+	})
+	return nil
+}
+
+type grpcConn struct {
+	stream    protocol.DistributedAssistant_ConnectServer
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (conn *grpcConn) ReadMessage(buffer []byte) ([]byte, error) {
+	frame := rawFrame{buffer: buffer}
+	err := conn.stream.RecvMsg(&frame)
+	if err != nil {
+		select {
+		case <-conn.closed:
+			return nil, net.ErrClosed
+		default:
+		}
+		return nil, err
+	}
+
+	if frame.size > len(buffer) {
+		return nil, &MessageTooLargeError{Size: frame.size, MaxSize: len(buffer)}
+	}
+	return buffer[:frame.size], nil
+}
+
+func (conn *grpcConn) WriteMessage(message []byte) error {
+	// gRPC may still send the frame after SendMsg returned.
+	buffer := append([]byte(nil), message...)
+	return conn.stream.SendMsg(&rawFrame{buffer: buffer, size: len(buffer)})
+}
+
+// Close ends the stream, the node has to open a new one.
+func (conn *grpcConn) Close() error {
+	conn.closeOnce.Do(func() {
+		close(conn.closed)
+	})
+	return nil
+}
+
+// rawFrame is a marshalled protobuf message. It lets the gRPC transport hand bytes to the network layer like the
+// other transports do, instead of unmarshalling every message twice.
+type rawFrame struct {
+	buffer []byte
+	size   int
+}
+
+// frameCodec passes rawFrames through and encodes everything else as protobuf. It is wire compatible with the
+// default codec, so nodes can use the generated client.
+type frameCodec struct{}
+
+func (frameCodec) Marshal(v any) ([]byte, error) {
+	if frame, ok := v.(*rawFrame); ok {
+		return frame.buffer[:frame.size], nil
+	}
+	return proto.Marshal(v.(proto.Message))
+}
+
+// Unmarshal copies the message into the buffer of the frame. Messages larger than the buffer are not copied, but
+// must not fail, since a failed receive ends the stream.
+func (frameCodec) Unmarshal(data []byte, v any) error {
+	if frame, ok := v.(*rawFrame); ok {
+		frame.size = len(data)
+		if frame.size <= len(frame.buffer) {
+			copy(frame.buffer, data)
+		}
+		return nil
+	}
+	return proto.Unmarshal(data, v.(proto.Message))
+}
+
+func (frameCodec) Name() string {
+	return "proto"
+}
+
+var _ encoding.Codec = frameCodec{}
@@ -1,63 +1,236 @@
 package network
 
 import (
-	"errors"
 	"fmt"
+	"github.com/FatProteins/master-thesis-code/metrics"
 	"github.com/FatProteins/master-thesis-code/network/protocol"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
-	"reflect"
+	"time"
 )
 
 type MessageType int
 
+// Peer identifies the node a connection belongs to, as announced in its handshake.
+type Peer struct {
+	NodeId       uint32
+	ProtocolName string
+	Identified   bool
+	Version      uint32
+	// SupportedActions are the verdicts the node can apply, all if empty.
+	SupportedActions []protocol.ActionType
+}
+
+// Supports reports whether the node can apply the action. Every node supports the noop action.
+func (peer Peer) Supports(actionType protocol.ActionType) bool {
+	if actionType == protocol.ActionType_NOOP_ACTION_TYPE || len(peer.SupportedActions) == 0 {
+		return true
+	}
+
+	for _, supported := range peer.SupportedActions {
+		if supported == actionType {
+			return true
+		}
+	}
+	return false
+}
+
+func (peer Peer) String() string {
+	if !peer.Identified {
+		return "anonymous peer"
+	}
+	return fmt.Sprintf("node %d (%s)", peer.NodeId, peer.ProtocolName)
+}
+
+// Message is a message of a node together with its response. It is owned by whoever received it from the
+// handle channel, i.e. the processor, until FreeMessage:
+//
+//   - Payload, GetResponse, Respond and ResetConn may only be called before FreeMessage, Respond at most once.
+//     ResetConnFunc returns a function that stays valid, e.g. for an action resetting the connection later.
+//   - FreeMessage must be called exactly once. Afterwards, neither the message, its embedded protocol.Message, its
+//     response nor its payload may be used, as they are reused for the next message read. Whatever must outlive
+//     the message, e.g. for events or the fault log, must be copied or decoded before.
+//   - Copies of a Message share its ownership, freeing one frees all of them.
+//
+// Freeing a message twice and responding to a freed message or twice are detected, counted in
+// da_message_misuses_total and otherwise ignored. Direct use of a freed protocol.Message cannot be detected, see
+// SetPoisonFreedMessages to catch it.
 type Message struct {
 	*protocol.Message
-	response      *protocol.Message
-	closeFunc     func(*protocol.Message)
-	respondFunc   func(*protocol.Message)
-	resetConnFunc func()
+	response   *protocol.Message
+	peer       Peer
+	received   time.Time
+	envelope   *envelope
+	generation uint64
+	owner      messageOwner
+}
+
+// messageOwner is where a message was received, i.e. the connection of a node. Messages refer to their owner
+// instead of closures, so handing a message to the processor allocates nothing.
+type messageOwner interface {
+	connId() uint64
+	attribute(node uint32)
+	respond(response *protocol.Message)
+	// release takes back the envelope of a freed message, it must poison instead of reuse it in poison mode.
+	release(envelope *envelope)
+	stale() bool
+	reset()
+	// resetFunc returns reset as function value. It is created once, creating it per message would allocate.
+	resetFunc() func()
+}
+
+// inProcessOwner hands responses of in-process messages to a function.
+type inProcessOwner struct {
+	respondFunc func(response *protocol.Message)
+}
+
+func (owner *inProcessOwner) connId() uint64 {
+	return 0
+}
+
+func (owner *inProcessOwner) attribute(uint32) {
+}
+
+func (owner *inProcessOwner) respond(response *protocol.Message) {
+	owner.respondFunc(response)
+}
+
+func (owner *inProcessOwner) release(envelope *envelope) {
+	if poisonFreed.Load() {
+		envelope.poison()
+	}
+}
+
+func (owner *inProcessOwner) stale() bool {
+	return false
+}
+
+func (owner *inProcessOwner) reset() {
+}
+
+func (owner *inProcessOwner) resetFunc() func() {
+	return func() {}
 }
 
+// NewMessage creates a message that is not received on a connection, e.g. from a node simulated in-process.
+// The response is handed to the respond function and must not be used after it returned. The message itself stays
+// owned by the caller, it is not poisoned when freed.
+func NewMessage(message *protocol.Message, peer Peer, respond func(response *protocol.Message)) Message {
+	envelope := &envelope{}
+	return Message{
+		Message:    message,
+		response:   &envelope.response,
+		peer:       peer,
+		received:   time.Now(),
+		envelope:   envelope,
+		generation: envelope.lease(),
+		owner:      &inProcessOwner{respondFunc: respond},
+	}
+}
+
+// FreeMessage ends the lifetime of the message and hands it back to the connection it was received on.
 func (message *Message) FreeMessage() {
-	message.closeFunc(message.Message)
-	message.closeFunc(message.response)
+	if !message.envelope.free(message.generation) {
+		misuse("double free")
+		return
+	}
+	message.owner.release(message.envelope)
+}
+
+// Stale reports whether the connection the message was received on is closed. Its response will be dropped.
+func (message *Message) Stale() bool {
+	return message.owner.stale()
+}
+
+func (message *Message) Peer() Peer {
+	return message.peer
+}
+
+// ConnId identifies the connection the message was received on. Messages of the same connection must be responded
+// to in order, messages of different connections are independent. All in-process messages share the ID 0.
+func (message *Message) ConnId() uint64 {
+	return message.owner.connId()
+}
+
+// AttributeTo sets the node an anonymous peer reports events of, e.g. taken from the payload of its message. The
+// heartbeats of the connection are attributed to that node, so they match the node faults are injected into.
+func (message *Message) AttributeTo(node uint32) {
+	message.owner.attribute(node)
+}
+
+// Received returns when the message was read from its connection.
+func (message *Message) Received() time.Time {
+	return message.received
+}
+
+// Payload returns the payload of the message, which is only valid until FreeMessage like the message itself.
+func (message *Message) Payload() *anypb.Any {
+	if !message.envelope.valid(message.generation) {
+		misuse("payload of freed message")
+	}
+	return message.MessageObject
 }
 
 func (message *Message) GetResponse() *protocol.Message {
+	if !message.envelope.valid(message.generation) {
+		misuse("response of freed message")
+	}
 	return message.response
 }
 
+// Respond writes the response. A second response or a response to a freed message is dropped, as it would be read
+// by the node as the response to its next message.
 func (message *Message) Respond() {
-	message.respondFunc(message.response)
+	if !message.envelope.valid(message.generation) {
+		misuse("respond to freed message")
+		return
+	}
+	if message.envelope.responded.Swap(true) {
+		misuse("second response")
+		return
+	}
+	message.owner.respond(message.response)
 }
 
 func (message *Message) ResetConn() {
-	message.resetConnFunc()
+	if !message.envelope.valid(message.generation) {
+		misuse("reset by freed message")
+		return
+	}
+	message.owner.reset()
 }
 
-const (
-	HEARTBEAT             = "HEARTBEAT"
-	VOTE_REQUEST_RECEIVED = "VOTE_REQUEST_RECEIVED"
-	VOTE_RECEIVED         = "VOTE_RECEIVED"
-	LOG_ENTRY_REPLICATED  = "LOG_ENTRY_REPLICATED"
-	LOG_ENTRY_COMMITTED   = "LOG_ENTRY_COMMITTED"
-	LEADER_SUSPECTED      = "LEADER_SUSPECTED"
-	FOLLOWER_SUSPECTED    = "FOLLOWER_SUSPECTED"
-)
+// ResetConnFunc returns ResetConn as function value, e.g. to pass it to an action.
+func (message *Message) ResetConnFunc() func() {
+	return message.owner.resetFunc()
+}
 
-func CastMessage[TargetMessageType protocol.Message](message *anypb.Any) (TargetMessageType, error) {
-	output := new(TargetMessageType)
-	msgBuf := protocol.Message{}
-	err := anypb.UnmarshalTo(message, &msgBuf, proto.UnmarshalOptions{})
-	if err != nil {
-		err = errors.Join(err, fmt.Errorf("could not cast message to '%s'", reflect.TypeOf(*output).Name()))
-		return *output, err
+// misuse reports a message used against its lifecycle. In poison mode it panics, so the stack shows the culprit.
+func misuse(kind string) {
+	metrics.MessageMisuses.WithLabelValues(kind).Inc()
+	if poisonFreed.Load() {
+		panic("network: " + kind)
 	}
-
-	return *output, nil
+	logger.Error("Message misuse: %s", kind)
 }
 
+const (
+	HEARTBEAT                = "HEARTBEAT"
+	VOTE_REQUEST_RECEIVED    = "VOTE_REQUEST_RECEIVED"
+	VOTE_RECEIVED            = "VOTE_RECEIVED"
+	LOG_ENTRY_REPLICATED     = "LOG_ENTRY_REPLICATED"
+	LOG_ENTRY_COMMITTED      = "LOG_ENTRY_COMMITTED"
+	LEADER_SUSPECTED         = "LEADER_SUSPECTED"
+	FOLLOWER_SUSPECTED       = "FOLLOWER_SUSPECTED"
+	HANDSHAKE                = "HANDSHAKE"
+	PRE_PREPARE_RECEIVED     = "PRE_PREPARE_RECEIVED"
+	PREPARE_RECEIVED         = "PREPARE_RECEIVED"
+	COMMIT_RECEIVED          = "COMMIT_RECEIVED"
+	VIEW_CHANGE_STARTED      = "VIEW_CHANGE_STARTED"
+	CHECKPOINT_REACHED       = "CHECKPOINT_REACHED"
+	STATE_TRANSFER_REQUESTED = "STATE_TRANSFER_REQUESTED"
+)
+
 func create() {
 	x := protocol.FollowerSuspected{LeaderId: 1, FollowerId: 1}
 	proto.Marshal(&x)
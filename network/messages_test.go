@@ -0,0 +1,203 @@
+package network
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/metrics"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// ownedNode is a node whose messages are handed to the test instead of a processor.
+type ownedNode struct {
+	*fakeNode
+	handleChan <-chan Message
+}
+
+func startOwnedNode(t *testing.T) *ownedNode {
+	t.Helper()
+	transport := testTransports(t)[0]
+	handleChan := make(chan Message, 100)
+	networkLayer, err := NewNetworkLayer(handleChan, nil, transport.transport, testMaxMessageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	networkLayer.RunAsync(ctx)
+
+	node := connect(t, transport)
+	node.handshake(1)
+	return &ownedNode{fakeNode: node, handleChan: handleChan}
+}
+
+// report sends a vote of the term and returns it as received by the DA.
+func (node *ownedNode) report(term uint64) Message {
+	node.t.Helper()
+	node.mustSend(voteMessage(node.t, term))
+	select {
+	case message := <-node.handleChan:
+		return message
+	case <-time.After(5 * time.Second):
+		node.t.Fatal("message not received within 5s")
+	}
+	return Message{}
+}
+
+// respond answers the message with its term and frees it.
+func respond(message Message) {
+	response := message.GetResponse()
+	response.Reset()
+	response.MessageType = protocol.MessageType_DA_RESPONSE
+	response.MessageObject = message.Payload()
+	message.Respond()
+	message.FreeMessage()
+}
+
+func setPoisonFreedMessages(t *testing.T, poison bool) {
+	SetPoisonFreedMessages(poison)
+	t.Cleanup(func() { SetPoisonFreedMessages(false) })
+}
+
+// misuses use a message against its lifecycle, the message was freed before unless noted otherwise.
+var misuses = []struct {
+	kind string
+	use  func(message Message)
+}{
+	{"double free", func(message Message) { message.FreeMessage() }},
+	{"payload of freed message", func(message Message) { message.Payload() }},
+	{"response of freed message", func(message Message) { message.GetResponse() }},
+	{"respond to freed message", func(message Message) { message.Respond() }},
+	{"reset by freed message", func(message Message) { message.ResetConn() }},
+}
+
+// misused returns the panic of the use, nil if it did not panic.
+func misused(use func()) (recovered any) {
+	defer func() { recovered = recover() }()
+	use()
+	return nil
+}
+
+func TestFreedMessagePanicsInPoisonMode(t *testing.T) {
+	setPoisonFreedMessages(t, true)
+	node := startOwnedNode(t)
+
+	for term, misuse := range misuses {
+		message := node.report(uint64(term + 1))
+		// A copy shares the ownership, so it is freed with the message.
+		owner := message
+		respond(owner)
+		expectTerms(t, []uint64{responseTerm(t, node.mustReceive())}, uint64(term+1))
+
+		counter := metrics.MessageMisuses.WithLabelValues(misuse.kind)
+		before := testutil.ToFloat64(counter)
+		recovered := misused(func() { misuse.use(message) })
+		if panicked, _ := recovered.(string); !strings.Contains(panicked, misuse.kind) {
+			t.Errorf("%s: got panic %v, want one naming the misuse", misuse.kind, recovered)
+		}
+		if count := testutil.ToFloat64(counter) - before; count != 1 {
+			t.Errorf("%s: counted %v misuses, want 1", misuse.kind, count)
+		}
+	}
+
+	// Direct use of the freed protocol.Message is not detected, but it reads garbage instead of the next message.
+	message := node.report(10)
+	respond(message)
+	node.mustReceive()
+	if message.MessageType != poisonMessageType || message.MessageObject.TypeUrl != poisonTypeUrl {
+		t.Errorf("freed message reads %v, want it poisoned", message.Message)
+	}
+	for _, value := range message.MessageObject.Value {
+		if value != poisonByte {
+			t.Fatalf("freed payload reads %x, want it poisoned", message.MessageObject.Value)
+		}
+	}
+}
+
+func TestSecondResponsePanicsInPoisonMode(t *testing.T) {
+	setPoisonFreedMessages(t, true)
+	node := startOwnedNode(t)
+
+	message := node.report(1)
+	message.Respond()
+	if recovered := misused(message.Respond); recovered == nil {
+		t.Error("second response did not panic")
+	}
+	message.FreeMessage()
+}
+
+// TestStaleGenerationPanicsInPoisonMode uses a freed message after the next message was received, which may be
+// read into the same envelope once it is not poisoned.
+func TestStaleGenerationPanicsInPoisonMode(t *testing.T) {
+	for _, poison := range []bool{true, false} {
+		setPoisonFreedMessages(t, poison)
+		node := startOwnedNode(t)
+
+		stale := node.report(1)
+		respond(stale)
+		next := node.report(2)
+		recovered := misused(stale.Respond)
+		if (recovered != nil) != poison {
+			t.Errorf("poison mode %t: got panic %v", poison, recovered)
+		}
+		if stale.envelope == next.envelope && poison {
+			t.Error("poisoned envelope was reused")
+		}
+
+		respond(next)
+		expectTerms(t, []uint64{responseTerm(t, node.mustReceive()), responseTerm(t, node.mustReceive())}, 1, 2)
+	}
+}
+
+// TestMisuseIsIgnoredWithoutPoisonMode counts every misuse, but neither panics nor writes a response.
+func TestMisuseIsIgnoredWithoutPoisonMode(t *testing.T) {
+	setPoisonFreedMessages(t, false)
+	node := startOwnedNode(t)
+
+	for term, misuse := range misuses {
+		message := node.report(uint64(term + 1))
+		respond(message)
+
+		counter := metrics.MessageMisuses.WithLabelValues(misuse.kind)
+		before := testutil.ToFloat64(counter)
+		if recovered := misused(func() { misuse.use(message) }); recovered != nil {
+			t.Errorf("%s: panicked with %v", misuse.kind, recovered)
+		}
+		if count := testutil.ToFloat64(counter) - before; count != 1 {
+			t.Errorf("%s: counted %v misuses, want 1", misuse.kind, count)
+		}
+	}
+
+	// Only the responses of the messages were written, the connection was not reset.
+	next := node.report(uint64(len(misuses) + 1))
+	respond(next)
+	terms := make([]uint64, 0, len(misuses)+1)
+	for range misuses {
+		terms = append(terms, responseTerm(t, node.mustReceive()))
+	}
+	expectTerms(t, append(terms, responseTerm(t, node.mustReceive())), 1, 2, 3, 4, 5, 6)
+}
+
+// TestResetConnFuncAfterResponse resets the connection after the message was responded to and freed, like a stop
+// action does.
+func TestResetConnFuncAfterResponse(t *testing.T) {
+	for _, poison := range []bool{true, false} {
+		setPoisonFreedMessages(t, poison)
+		node := startOwnedNode(t)
+
+		message := node.report(1)
+		reset := message.ResetConnFunc()
+		respond(message)
+		expectTerms(t, []uint64{responseTerm(t, node.mustReceive())}, 1)
+
+		if recovered := misused(reset); recovered != nil {
+			t.Fatalf("poison mode %t: reset panicked with %v", poison, recovered)
+		}
+		if response, err := node.receive(); err == nil {
+			t.Errorf("poison mode %t: connection still open, received %v", poison, response)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package network
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const testMaxMessageSize = 4096
+
+// testTransport is a transport the tests run against, together with how a fake node dials it.
+type testTransport struct {
+	name      string
+	transport Transport
+	dial      func(t *testing.T) fakeConn
+}
+
+func testTransports(t *testing.T) []testTransport {
+	t.Helper()
+	unixPath := filepath.Join(t.TempDir(), "da.sock")
+	packetPath := filepath.Join(t.TempDir(), "da-packet.sock")
+	tcpAddress := freeAddress(t)
+	grpcAddress := freeAddress(t)
+
+	unixTransport, err := NewUnixTransport(SocketTypeStream, unixPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packetTransport, err := NewUnixTransport(SocketTypePacket, packetPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return []testTransport{
+		{"unix", unixTransport, func(t *testing.T) fakeConn { return dialStream(t, "unix", unixPath) }},
+		{"unixpacket", packetTransport, func(t *testing.T) fakeConn { return dialPacket(t, packetPath) }},
+		{"tcp", NewTcpTransport(tcpAddress), func(t *testing.T) fakeConn { return dialStream(t, "tcp", tcpAddress) }},
+		{"grpc", NewGrpcTransport(grpcAddress, testMaxMessageSize), func(t *testing.T) fakeConn { return dialGrpc(t, grpcAddress) }},
+	}
+}
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// startNetworkLayer runs a network layer on the transport. Its messages are answered by an echo processor, see
+// echo.
+func startNetworkLayer(t *testing.T, transport Transport) *NetworkLayer {
+	t.Helper()
+	return startSlowNetworkLayer(t, transport, 0)
+}
+
+// startSlowNetworkLayer runs a network layer whose echo processor takes the delay to handle every message.
+func startSlowNetworkLayer(t *testing.T, transport Transport, delay time.Duration) *NetworkLayer {
+	t.Helper()
+	handleChan := make(chan Message, 100)
+	networkLayer, err := NewNetworkLayer(handleChan, nil, transport, testMaxMessageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	networkLayer.RunAsync(ctx)
+	go echo(ctx, handleChan, delay)
+	return networkLayer
+}
+
+// echo answers every message with its own payload, so the fake node can tell which message a response belongs to.
+func echo(ctx context.Context, handleChan <-chan Message, delay time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message := <-handleChan:
+			time.Sleep(delay)
+			response := message.GetResponse()
+			response.Reset()
+			response.MessageType = protocol.MessageType_DA_RESPONSE
+			response.ActionType = message.ActionType
+			response.MessageObject = proto.Clone(message.MessageObject).(*anypb.Any)
+			message.Respond()
+			message.FreeMessage()
+		}
+	}
+}
+
+// fakeNode is an instrumented node connected to the DA.
+type fakeNode struct {
+	t *testing.T
+	fakeConn
+}
+
+// fakeConn is the node side of a transport.
+type fakeConn interface {
+	// write sends raw bytes, i.e. framed messages on stream sockets and a single message otherwise.
+	write(data []byte) error
+	send(message *protocol.Message) error
+	receive() (*protocol.Message, error)
+	close() error
+}
+
+// connect dials the DA until it listens.
+func connect(t *testing.T, transport testTransport) *fakeNode {
+	t.Helper()
+	node := &fakeNode{t: t, fakeConn: transport.dial(t)}
+	t.Cleanup(func() { _ = node.close() })
+	return node
+}
+
+// handshake identifies the node and waits for the acknowledgement.
+func (node *fakeNode) handshake(nodeId uint32) {
+	node.t.Helper()
+	messageObject, err := anypb.New(&protocol.Handshake{NodeId: nodeId, ProtocolName: "raft", Version: protocol.Version})
+	if err != nil {
+		node.t.Fatal(err)
+	}
+	node.mustSend(&protocol.Message{MessageType: protocol.MessageType_HANDSHAKE, MessageObject: messageObject})
+
+	ack, err := CastMessage[*protocol.HandshakeAck](node.mustReceive().MessageObject)
+	if err != nil || !ack.Accepted {
+		node.t.Fatalf("handshake not accepted: %v, %v", ack, err)
+	}
+}
+
+func (node *fakeNode) mustSend(message *protocol.Message) {
+	node.t.Helper()
+	err := node.send(message)
+	if err != nil {
+		node.t.Fatalf("failed to send: %v", err)
+	}
+}
+
+func (node *fakeNode) mustReceive() *protocol.Message {
+	node.t.Helper()
+	received := make(chan *protocol.Message, 1)
+	failed := make(chan error, 1)
+	go func() {
+		response, err := node.receive()
+		if err != nil {
+			failed <- err
+			return
+		}
+		received <- response
+	}()
+
+	select {
+	case response := <-received:
+		return response
+	case err := <-failed:
+		node.t.Fatalf("failed to receive: %v", err)
+	case <-time.After(5 * time.Second):
+		node.t.Fatal("no response within 5s")
+	}
+	return nil
+}
+
+// voteMessage is a message the echo processor answers with the term, to match responses to messages.
+func voteMessage(t *testing.T, term uint64) *protocol.Message {
+	t.Helper()
+	messageObject, err := anypb.New(&protocol.VoteRequestReceived{RequestingNodeId: 1, ReceivingNodeId: 2, Term: term})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &protocol.Message{MessageType: protocol.MessageType_VOTE_REQUEST_RECEIVED, MessageObject: messageObject}
+}
+
+// responseTerm returns the term of the vote message a response belongs to, 0 for default responses.
+func responseTerm(t *testing.T, response *protocol.Message) uint64 {
+	t.Helper()
+	if len(response.MessageObject.GetTypeUrl()) == 0 {
+		return 0
+	}
+	vote, err := CastMessage[*protocol.VoteRequestReceived](response.MessageObject)
+	if err != nil {
+		t.Fatalf("unexpected response payload: %v", err)
+	}
+	return vote.Term
+}
+
+func marshal(t *testing.T, message *protocol.Message) []byte {
+	t.Helper()
+	messageBytes, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messageBytes
+}
+
+func frame(t *testing.T, message *protocol.Message) []byte {
+	t.Helper()
+	messageBytes := marshal(t, message)
+	return append(binary.AppendUvarint(nil, uint64(len(messageBytes))), messageBytes...)
+}
+
+func dialRetrying(t *testing.T, network string, address string) net.Conn {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		connection, err := net.Dial(network, address)
+		if err == nil {
+			return connection
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("failed to dial %s://%s: %v", network, address, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+type fakeStreamConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func dialStream(t *testing.T, network string, address string) fakeConn {
+	connection := dialRetrying(t, network, address)
+	return &fakeStreamConn{Conn: connection, reader: bufio.NewReader(connection)}
+}
+
+func (conn *fakeStreamConn) write(data []byte) error {
+	_, err := conn.Write(data)
+	return err
+}
+
+func (conn *fakeStreamConn) send(message *protocol.Message) error {
+	messageBytes, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return conn.write(append(binary.AppendUvarint(nil, uint64(len(messageBytes))), messageBytes...))
+}
+
+func (conn *fakeStreamConn) receive() (*protocol.Message, error) {
+	size, err := binary.ReadUvarint(conn.reader)
+	if err != nil {
+		return nil, err
+	}
+	messageBytes := make([]byte, size)
+	_, err = io.ReadFull(conn.reader, messageBytes)
+	if err != nil {
+		return nil, err
+	}
+	response := &protocol.Message{}
+	return response, proto.Unmarshal(messageBytes, response)
+}
+
+func (conn *fakeStreamConn) close() error {
+	return conn.Close()
+}
+
+type fakePacketConn struct {
+	net.Conn
+}
+
+func dialPacket(t *testing.T, path string) fakeConn {
+	return &fakePacketConn{Conn: dialRetrying(t, "unixpacket", path)}
+}
+
+func (conn *fakePacketConn) write(data []byte) error {
+	_, err := conn.Write(data)
+	return err
+}
+
+func (conn *fakePacketConn) send(message *protocol.Message) error {
+	messageBytes, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return conn.write(messageBytes)
+}
+
+func (conn *fakePacketConn) receive() (*protocol.Message, error) {
+	buffer := make([]byte, testMaxMessageSize)
+	bytesRead, err := conn.Read(buffer)
+	if err != nil {
+		return nil, err
+	}
+	response := &protocol.Message{}
+	return response, proto.Unmarshal(buffer[:bytesRead], response)
+}
+
+func (conn *fakePacketConn) close() error {
+	return conn.Close()
+}
+
+type fakeGrpcConn struct {
+	connection *grpc.ClientConn
+	stream     protocol.DistributedAssistant_ConnectClient
+	cancel     context.CancelFunc
+}
+
+func dialGrpc(t *testing.T, address string) fakeConn {
+	t.Helper()
+	connection, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		stream, err := protocol.NewDistributedAssistantClient(connection).Connect(ctx, grpc.WaitForReady(true))
+		if err == nil {
+			return &fakeGrpcConn{connection: connection, stream: stream, cancel: cancel}
+		}
+		cancel()
+		if time.Now().After(deadline) {
+			t.Fatalf("failed to open stream to %s: %v", address, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (conn *fakeGrpcConn) write(data []byte) error {
+	message := &protocol.Message{}
+	err := proto.Unmarshal(data, message)
+	if err != nil {
+		return err
+	}
+	return conn.send(message)
+}
+
+func (conn *fakeGrpcConn) send(message *protocol.Message) error {
+	return conn.stream.Send(message)
+}
+
+func (conn *fakeGrpcConn) receive() (*protocol.Message, error) {
+	return conn.stream.Recv()
+}
+
+func (conn *fakeGrpcConn) close() error {
+	conn.cancel()
+	return errors.Join(conn.stream.CloseSend(), conn.connection.Close())
+}
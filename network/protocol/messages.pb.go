@@ -32,29 +32,51 @@ const (
 	MessageType_LOG_ENTRY_COMMITTED   MessageType = 5
 	MessageType_LEADER_SUSPECTED      MessageType = 6
 	MessageType_FOLLOWER_SUSPECTED    MessageType = 7
+	MessageType_HANDSHAKE             MessageType = 8
+	// PBFT family. BFT-SMaRt calls the phases PROPOSE, WRITE and ACCEPT and view changes regency changes.
+	MessageType_PRE_PREPARE_RECEIVED     MessageType = 9
+	MessageType_PREPARE_RECEIVED         MessageType = 10
+	MessageType_COMMIT_RECEIVED          MessageType = 11
+	MessageType_VIEW_CHANGE_STARTED      MessageType = 12
+	MessageType_CHECKPOINT_REACHED       MessageType = 13
+	MessageType_STATE_TRANSFER_REQUESTED MessageType = 14
 )
 
 // Enum value maps for MessageType.
 var (
 	MessageType_name = map[int32]string{
-		0: "DA_RESPONSE",
-		1: "HEARTBEAT",
-		2: "VOTE_REQUEST_RECEIVED",
-		3: "VOTE_RECEIVED",
-		4: "LOG_ENTRY_REPLICATED",
-		5: "LOG_ENTRY_COMMITTED",
-		6: "LEADER_SUSPECTED",
-		7: "FOLLOWER_SUSPECTED",
+		0:  "DA_RESPONSE",
+		1:  "HEARTBEAT",
+		2:  "VOTE_REQUEST_RECEIVED",
+		3:  "VOTE_RECEIVED",
+		4:  "LOG_ENTRY_REPLICATED",
+		5:  "LOG_ENTRY_COMMITTED",
+		6:  "LEADER_SUSPECTED",
+		7:  "FOLLOWER_SUSPECTED",
+		8:  "HANDSHAKE",
+		9:  "PRE_PREPARE_RECEIVED",
+		10: "PREPARE_RECEIVED",
+		11: "COMMIT_RECEIVED",
+		12: "VIEW_CHANGE_STARTED",
+		13: "CHECKPOINT_REACHED",
+		14: "STATE_TRANSFER_REQUESTED",
 	}
 	MessageType_value = map[string]int32{
-		"DA_RESPONSE":           0,
-		"HEARTBEAT":             1,
-		"VOTE_REQUEST_RECEIVED": 2,
-		"VOTE_RECEIVED":         3,
-		"LOG_ENTRY_REPLICATED":  4,
-		"LOG_ENTRY_COMMITTED":   5,
-		"LEADER_SUSPECTED":      6,
-		"FOLLOWER_SUSPECTED":    7,
+		"DA_RESPONSE":              0,
+		"HEARTBEAT":                1,
+		"VOTE_REQUEST_RECEIVED":    2,
+		"VOTE_RECEIVED":            3,
+		"LOG_ENTRY_REPLICATED":     4,
+		"LOG_ENTRY_COMMITTED":      5,
+		"LEADER_SUSPECTED":         6,
+		"FOLLOWER_SUSPECTED":       7,
+		"HANDSHAKE":                8,
+		"PRE_PREPARE_RECEIVED":     9,
+		"PREPARE_RECEIVED":         10,
+		"COMMIT_RECEIVED":          11,
+		"VIEW_CHANGE_STARTED":      12,
+		"CHECKPOINT_REACHED":       13,
+		"STATE_TRANSFER_REQUESTED": 14,
 	}
 )
 
@@ -258,6 +280,144 @@ func (x *DAResponse) GetResponseType() string {
 	return ""
 }
 
+type Handshake struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	NodeId       uint32 `protobuf:"varint,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	ProtocolName string `protobuf:"bytes,2,opt,name=protocolName,proto3" json:"protocolName,omitempty"`
+	// version is the version of this protocol the node speaks. 0 is treated as 1, the version without it.
+	Version uint32 `protobuf:"varint,3,opt,name=version,proto3" json:"version,omitempty"`
+	// supportedActions are the verdicts the node can apply. If empty, the node supports all actions.
+	SupportedActions []ActionType `protobuf:"varint,4,rep,packed,name=supportedActions,proto3,enum=ActionType" json:"supportedActions,omitempty"`
+}
+
+func (x *Handshake) Reset() {
+	*x = Handshake{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocol_messages_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Handshake) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Handshake) ProtoMessage() {}
+
+func (x *Handshake) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Handshake.ProtoReflect.Descriptor instead.
+func (*Handshake) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Handshake) GetNodeId() uint32 {
+	if x != nil {
+		return x.NodeId
+	}
+	return 0
+}
+
+func (x *Handshake) GetProtocolName() string {
+	if x != nil {
+		return x.ProtocolName
+	}
+	return ""
+}
+
+func (x *Handshake) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Handshake) GetSupportedActions() []ActionType {
+	if x != nil {
+		return x.SupportedActions
+	}
+	return nil
+}
+
+// HandshakeAck is the payload of the DA response to a handshake. After rejecting a node, the DA closes the
+// connection.
+type HandshakeAck struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Accepted bool   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Version  uint32 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	Reason   string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+}
+
+func (x *HandshakeAck) Reset() {
+	*x = HandshakeAck{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocol_messages_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HandshakeAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HandshakeAck) ProtoMessage() {}
+
+func (x *HandshakeAck) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HandshakeAck.ProtoReflect.Descriptor instead.
+func (*HandshakeAck) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *HandshakeAck) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *HandshakeAck) GetVersion() uint32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *HandshakeAck) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 type CustomData struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -270,7 +430,7 @@ type CustomData struct {
 func (x *CustomData) Reset() {
 	*x = CustomData{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protocol_messages_proto_msgTypes[2]
+		mi := &file_protocol_messages_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -283,7 +443,369 @@ func (x *CustomData) String() string {
 func (*CustomData) ProtoMessage() {}
 
 func (x *CustomData) ProtoReflect() protoreflect.Message {
-	mi := &file_protocol_messages_proto_msgTypes[2]
+	mi := &file_protocol_messages_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CustomData.ProtoReflect.Descriptor instead.
+func (*CustomData) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CustomData) GetProtocolName() string {
+	if x != nil {
+		return x.ProtocolName
+	}
+	return ""
+}
+
+func (x *CustomData) GetData() *anypb.Any {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type VoteRequestReceived struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestingNodeId uint32 `protobuf:"varint,1,opt,name=requestingNodeId,proto3" json:"requestingNodeId,omitempty"`
+	ReceivingNodeId  uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
+	Term             uint64 `protobuf:"varint,3,opt,name=term,proto3" json:"term,omitempty"`
+}
+
+func (x *VoteRequestReceived) Reset() {
+	*x = VoteRequestReceived{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocol_messages_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VoteRequestReceived) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteRequestReceived) ProtoMessage() {}
+
+func (x *VoteRequestReceived) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteRequestReceived.ProtoReflect.Descriptor instead.
+func (*VoteRequestReceived) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *VoteRequestReceived) GetRequestingNodeId() uint32 {
+	if x != nil {
+		return x.RequestingNodeId
+	}
+	return 0
+}
+
+func (x *VoteRequestReceived) GetReceivingNodeId() uint32 {
+	if x != nil {
+		return x.ReceivingNodeId
+	}
+	return 0
+}
+
+func (x *VoteRequestReceived) GetTerm() uint64 {
+	if x != nil {
+		return x.Term
+	}
+	return 0
+}
+
+type VoteReceived struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	VotingNodeId uint32 `protobuf:"varint,1,opt,name=votingNodeId,proto3" json:"votingNodeId,omitempty"`
+	VotedNodeId  uint32 `protobuf:"varint,2,opt,name=votedNodeId,proto3" json:"votedNodeId,omitempty"`
+	VoteGranted  bool   `protobuf:"varint,3,opt,name=voteGranted,proto3" json:"voteGranted,omitempty"`
+}
+
+func (x *VoteReceived) Reset() {
+	*x = VoteReceived{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocol_messages_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VoteReceived) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VoteReceived) ProtoMessage() {}
+
+func (x *VoteReceived) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VoteReceived.ProtoReflect.Descriptor instead.
+func (*VoteReceived) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *VoteReceived) GetVotingNodeId() uint32 {
+	if x != nil {
+		return x.VotingNodeId
+	}
+	return 0
+}
+
+func (x *VoteReceived) GetVotedNodeId() uint32 {
+	if x != nil {
+		return x.VotedNodeId
+	}
+	return 0
+}
+
+func (x *VoteReceived) GetVoteGranted() bool {
+	if x != nil {
+		return x.VoteGranted
+	}
+	return false
+}
+
+type LogEntryReplicated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LeaderId        uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
+	ReceivingNodeId uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
+	LogEntryNumber  int64  `protobuf:"varint,3,opt,name=logEntryNumber,proto3" json:"logEntryNumber,omitempty"`
+}
+
+func (x *LogEntryReplicated) Reset() {
+	*x = LogEntryReplicated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocol_messages_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogEntryReplicated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntryReplicated) ProtoMessage() {}
+
+func (x *LogEntryReplicated) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntryReplicated.ProtoReflect.Descriptor instead.
+func (*LogEntryReplicated) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *LogEntryReplicated) GetLeaderId() uint32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *LogEntryReplicated) GetReceivingNodeId() uint32 {
+	if x != nil {
+		return x.ReceivingNodeId
+	}
+	return 0
+}
+
+func (x *LogEntryReplicated) GetLogEntryNumber() int64 {
+	if x != nil {
+		return x.LogEntryNumber
+	}
+	return 0
+}
+
+type LogEntryCommitted struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LeaderId        uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
+	ReceivingNodeId uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
+	LogEntryNumber  int64  `protobuf:"varint,3,opt,name=logEntryNumber,proto3" json:"logEntryNumber,omitempty"`
+}
+
+func (x *LogEntryCommitted) Reset() {
+	*x = LogEntryCommitted{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocol_messages_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LogEntryCommitted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogEntryCommitted) ProtoMessage() {}
+
+func (x *LogEntryCommitted) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogEntryCommitted.ProtoReflect.Descriptor instead.
+func (*LogEntryCommitted) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *LogEntryCommitted) GetLeaderId() uint32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *LogEntryCommitted) GetReceivingNodeId() uint32 {
+	if x != nil {
+		return x.ReceivingNodeId
+	}
+	return 0
+}
+
+func (x *LogEntryCommitted) GetLogEntryNumber() int64 {
+	if x != nil {
+		return x.LogEntryNumber
+	}
+	return 0
+}
+
+type LeaderSuspected struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LeaderId         uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
+	SuspectingNodeId uint32 `protobuf:"varint,2,opt,name=suspectingNodeId,proto3" json:"suspectingNodeId,omitempty"`
+}
+
+func (x *LeaderSuspected) Reset() {
+	*x = LeaderSuspected{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocol_messages_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LeaderSuspected) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeaderSuspected) ProtoMessage() {}
+
+func (x *LeaderSuspected) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeaderSuspected.ProtoReflect.Descriptor instead.
+func (*LeaderSuspected) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *LeaderSuspected) GetLeaderId() uint32 {
+	if x != nil {
+		return x.LeaderId
+	}
+	return 0
+}
+
+func (x *LeaderSuspected) GetSuspectingNodeId() uint32 {
+	if x != nil {
+		return x.SuspectingNodeId
+	}
+	return 0
+}
+
+type FollowerSuspected struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	LeaderId   uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
+	FollowerId uint32 `protobuf:"varint,2,opt,name=followerId,proto3" json:"followerId,omitempty"`
+}
+
+func (x *FollowerSuspected) Reset() {
+	*x = FollowerSuspected{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocol_messages_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FollowerSuspected) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FollowerSuspected) ProtoMessage() {}
+
+func (x *FollowerSuspected) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -294,51 +816,53 @@ func (x *CustomData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CustomData.ProtoReflect.Descriptor instead.
-func (*CustomData) Descriptor() ([]byte, []int) {
-	return file_protocol_messages_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use FollowerSuspected.ProtoReflect.Descriptor instead.
+func (*FollowerSuspected) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *CustomData) GetProtocolName() string {
+func (x *FollowerSuspected) GetLeaderId() uint32 {
 	if x != nil {
-		return x.ProtocolName
+		return x.LeaderId
 	}
-	return ""
+	return 0
 }
 
-func (x *CustomData) GetData() *anypb.Any {
+func (x *FollowerSuspected) GetFollowerId() uint32 {
 	if x != nil {
-		return x.Data
+		return x.FollowerId
 	}
-	return nil
+	return 0
 }
 
-type VoteRequestReceived struct {
+type PrePrepareReceived struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	RequestingNodeId uint32 `protobuf:"varint,1,opt,name=requestingNodeId,proto3" json:"requestingNodeId,omitempty"`
-	ReceivingNodeId  uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
+	LeaderId        uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
+	ReceivingNodeId uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
+	View            uint64 `protobuf:"varint,3,opt,name=view,proto3" json:"view,omitempty"`
+	SequenceNumber  uint64 `protobuf:"varint,4,opt,name=sequenceNumber,proto3" json:"sequenceNumber,omitempty"`
 }
 
-func (x *VoteRequestReceived) Reset() {
-	*x = VoteRequestReceived{}
+func (x *PrePrepareReceived) Reset() {
+	*x = PrePrepareReceived{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protocol_messages_proto_msgTypes[3]
+		mi := &file_protocol_messages_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *VoteRequestReceived) String() string {
+func (x *PrePrepareReceived) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VoteRequestReceived) ProtoMessage() {}
+func (*PrePrepareReceived) ProtoMessage() {}
 
-func (x *VoteRequestReceived) ProtoReflect() protoreflect.Message {
-	mi := &file_protocol_messages_proto_msgTypes[3]
+func (x *PrePrepareReceived) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -349,52 +873,67 @@ func (x *VoteRequestReceived) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VoteRequestReceived.ProtoReflect.Descriptor instead.
-func (*VoteRequestReceived) Descriptor() ([]byte, []int) {
-	return file_protocol_messages_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use PrePrepareReceived.ProtoReflect.Descriptor instead.
+func (*PrePrepareReceived) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *VoteRequestReceived) GetRequestingNodeId() uint32 {
+func (x *PrePrepareReceived) GetLeaderId() uint32 {
 	if x != nil {
-		return x.RequestingNodeId
+		return x.LeaderId
 	}
 	return 0
 }
 
-func (x *VoteRequestReceived) GetReceivingNodeId() uint32 {
+func (x *PrePrepareReceived) GetReceivingNodeId() uint32 {
 	if x != nil {
 		return x.ReceivingNodeId
 	}
 	return 0
 }
 
-type VoteReceived struct {
+func (x *PrePrepareReceived) GetView() uint64 {
+	if x != nil {
+		return x.View
+	}
+	return 0
+}
+
+func (x *PrePrepareReceived) GetSequenceNumber() uint64 {
+	if x != nil {
+		return x.SequenceNumber
+	}
+	return 0
+}
+
+type PrepareReceived struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	VotingNodeId uint32 `protobuf:"varint,1,opt,name=votingNodeId,proto3" json:"votingNodeId,omitempty"`
-	VotedNodeId  uint32 `protobuf:"varint,2,opt,name=votedNodeId,proto3" json:"votedNodeId,omitempty"`
-	VoteGranted  bool   `protobuf:"varint,3,opt,name=voteGranted,proto3" json:"voteGranted,omitempty"`
+	SendingNodeId   uint32 `protobuf:"varint,1,opt,name=sendingNodeId,proto3" json:"sendingNodeId,omitempty"`
+	ReceivingNodeId uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
+	View            uint64 `protobuf:"varint,3,opt,name=view,proto3" json:"view,omitempty"`
+	SequenceNumber  uint64 `protobuf:"varint,4,opt,name=sequenceNumber,proto3" json:"sequenceNumber,omitempty"`
 }
 
-func (x *VoteReceived) Reset() {
-	*x = VoteReceived{}
+func (x *PrepareReceived) Reset() {
+	*x = PrepareReceived{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protocol_messages_proto_msgTypes[4]
+		mi := &file_protocol_messages_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *VoteReceived) String() string {
+func (x *PrepareReceived) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VoteReceived) ProtoMessage() {}
+func (*PrepareReceived) ProtoMessage() {}
 
-func (x *VoteReceived) ProtoReflect() protoreflect.Message {
-	mi := &file_protocol_messages_proto_msgTypes[4]
+func (x *PrepareReceived) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -405,59 +944,67 @@ func (x *VoteReceived) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VoteReceived.ProtoReflect.Descriptor instead.
-func (*VoteReceived) Descriptor() ([]byte, []int) {
-	return file_protocol_messages_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use PrepareReceived.ProtoReflect.Descriptor instead.
+func (*PrepareReceived) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *VoteReceived) GetVotingNodeId() uint32 {
+func (x *PrepareReceived) GetSendingNodeId() uint32 {
 	if x != nil {
-		return x.VotingNodeId
+		return x.SendingNodeId
 	}
 	return 0
 }
 
-func (x *VoteReceived) GetVotedNodeId() uint32 {
+func (x *PrepareReceived) GetReceivingNodeId() uint32 {
 	if x != nil {
-		return x.VotedNodeId
+		return x.ReceivingNodeId
 	}
 	return 0
 }
 
-func (x *VoteReceived) GetVoteGranted() bool {
+func (x *PrepareReceived) GetView() uint64 {
 	if x != nil {
-		return x.VoteGranted
+		return x.View
 	}
-	return false
+	return 0
 }
 
-type LogEntryReplicated struct {
+func (x *PrepareReceived) GetSequenceNumber() uint64 {
+	if x != nil {
+		return x.SequenceNumber
+	}
+	return 0
+}
+
+type CommitReceived struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	LeaderId        uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
+	SendingNodeId   uint32 `protobuf:"varint,1,opt,name=sendingNodeId,proto3" json:"sendingNodeId,omitempty"`
 	ReceivingNodeId uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
-	LogEntryNumber  int64  `protobuf:"varint,3,opt,name=logEntryNumber,proto3" json:"logEntryNumber,omitempty"`
+	View            uint64 `protobuf:"varint,3,opt,name=view,proto3" json:"view,omitempty"`
+	SequenceNumber  uint64 `protobuf:"varint,4,opt,name=sequenceNumber,proto3" json:"sequenceNumber,omitempty"`
 }
 
-func (x *LogEntryReplicated) Reset() {
-	*x = LogEntryReplicated{}
+func (x *CommitReceived) Reset() {
+	*x = CommitReceived{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protocol_messages_proto_msgTypes[5]
+		mi := &file_protocol_messages_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *LogEntryReplicated) String() string {
+func (x *CommitReceived) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogEntryReplicated) ProtoMessage() {}
+func (*CommitReceived) ProtoMessage() {}
 
-func (x *LogEntryReplicated) ProtoReflect() protoreflect.Message {
-	mi := &file_protocol_messages_proto_msgTypes[5]
+func (x *CommitReceived) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -468,59 +1015,66 @@ func (x *LogEntryReplicated) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogEntryReplicated.ProtoReflect.Descriptor instead.
-func (*LogEntryReplicated) Descriptor() ([]byte, []int) {
-	return file_protocol_messages_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use CommitReceived.ProtoReflect.Descriptor instead.
+func (*CommitReceived) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *LogEntryReplicated) GetLeaderId() uint32 {
+func (x *CommitReceived) GetSendingNodeId() uint32 {
 	if x != nil {
-		return x.LeaderId
+		return x.SendingNodeId
 	}
 	return 0
 }
 
-func (x *LogEntryReplicated) GetReceivingNodeId() uint32 {
+func (x *CommitReceived) GetReceivingNodeId() uint32 {
 	if x != nil {
 		return x.ReceivingNodeId
 	}
 	return 0
 }
 
-func (x *LogEntryReplicated) GetLogEntryNumber() int64 {
+func (x *CommitReceived) GetView() uint64 {
 	if x != nil {
-		return x.LogEntryNumber
+		return x.View
 	}
 	return 0
 }
 
-type LogEntryCommitted struct {
+func (x *CommitReceived) GetSequenceNumber() uint64 {
+	if x != nil {
+		return x.SequenceNumber
+	}
+	return 0
+}
+
+type ViewChangeStarted struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	LeaderId        uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
-	ReceivingNodeId uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
-	LogEntryNumber  int64  `protobuf:"varint,3,opt,name=logEntryNumber,proto3" json:"logEntryNumber,omitempty"`
+	NodeId            uint32 `protobuf:"varint,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	SuspectedLeaderId uint32 `protobuf:"varint,2,opt,name=suspectedLeaderId,proto3" json:"suspectedLeaderId,omitempty"`
+	NewView           uint64 `protobuf:"varint,3,opt,name=newView,proto3" json:"newView,omitempty"`
 }
 
-func (x *LogEntryCommitted) Reset() {
-	*x = LogEntryCommitted{}
+func (x *ViewChangeStarted) Reset() {
+	*x = ViewChangeStarted{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protocol_messages_proto_msgTypes[6]
+		mi := &file_protocol_messages_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *LogEntryCommitted) String() string {
+func (x *ViewChangeStarted) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogEntryCommitted) ProtoMessage() {}
+func (*ViewChangeStarted) ProtoMessage() {}
 
-func (x *LogEntryCommitted) ProtoReflect() protoreflect.Message {
-	mi := &file_protocol_messages_proto_msgTypes[6]
+func (x *ViewChangeStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -531,58 +1085,58 @@ func (x *LogEntryCommitted) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogEntryCommitted.ProtoReflect.Descriptor instead.
-func (*LogEntryCommitted) Descriptor() ([]byte, []int) {
-	return file_protocol_messages_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use ViewChangeStarted.ProtoReflect.Descriptor instead.
+func (*ViewChangeStarted) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *LogEntryCommitted) GetLeaderId() uint32 {
+func (x *ViewChangeStarted) GetNodeId() uint32 {
 	if x != nil {
-		return x.LeaderId
+		return x.NodeId
 	}
 	return 0
 }
 
-func (x *LogEntryCommitted) GetReceivingNodeId() uint32 {
+func (x *ViewChangeStarted) GetSuspectedLeaderId() uint32 {
 	if x != nil {
-		return x.ReceivingNodeId
+		return x.SuspectedLeaderId
 	}
 	return 0
 }
 
-func (x *LogEntryCommitted) GetLogEntryNumber() int64 {
+func (x *ViewChangeStarted) GetNewView() uint64 {
 	if x != nil {
-		return x.LogEntryNumber
+		return x.NewView
 	}
 	return 0
 }
 
-type LeaderSuspected struct {
+type CheckpointReached struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	LeaderId         uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
-	SuspectingNodeId uint32 `protobuf:"varint,2,opt,name=suspectingNodeId,proto3" json:"suspectingNodeId,omitempty"`
+	NodeId         uint32 `protobuf:"varint,1,opt,name=nodeId,proto3" json:"nodeId,omitempty"`
+	SequenceNumber uint64 `protobuf:"varint,2,opt,name=sequenceNumber,proto3" json:"sequenceNumber,omitempty"`
 }
 
-func (x *LeaderSuspected) Reset() {
-	*x = LeaderSuspected{}
+func (x *CheckpointReached) Reset() {
+	*x = CheckpointReached{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protocol_messages_proto_msgTypes[7]
+		mi := &file_protocol_messages_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *LeaderSuspected) String() string {
+func (x *CheckpointReached) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LeaderSuspected) ProtoMessage() {}
+func (*CheckpointReached) ProtoMessage() {}
 
-func (x *LeaderSuspected) ProtoReflect() protoreflect.Message {
-	mi := &file_protocol_messages_proto_msgTypes[7]
+func (x *CheckpointReached) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -593,51 +1147,52 @@ func (x *LeaderSuspected) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LeaderSuspected.ProtoReflect.Descriptor instead.
-func (*LeaderSuspected) Descriptor() ([]byte, []int) {
-	return file_protocol_messages_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use CheckpointReached.ProtoReflect.Descriptor instead.
+func (*CheckpointReached) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *LeaderSuspected) GetLeaderId() uint32 {
+func (x *CheckpointReached) GetNodeId() uint32 {
 	if x != nil {
-		return x.LeaderId
+		return x.NodeId
 	}
 	return 0
 }
 
-func (x *LeaderSuspected) GetSuspectingNodeId() uint32 {
+func (x *CheckpointReached) GetSequenceNumber() uint64 {
 	if x != nil {
-		return x.SuspectingNodeId
+		return x.SequenceNumber
 	}
 	return 0
 }
 
-type FollowerSuspected struct {
+type StateTransferRequested struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	LeaderId   uint32 `protobuf:"varint,1,opt,name=leaderId,proto3" json:"leaderId,omitempty"`
-	FollowerId uint32 `protobuf:"varint,2,opt,name=followerId,proto3" json:"followerId,omitempty"`
+	RequestingNodeId   uint32 `protobuf:"varint,1,opt,name=requestingNodeId,proto3" json:"requestingNodeId,omitempty"`
+	ReceivingNodeId    uint32 `protobuf:"varint,2,opt,name=receivingNodeId,proto3" json:"receivingNodeId,omitempty"`
+	LastSequenceNumber uint64 `protobuf:"varint,3,opt,name=lastSequenceNumber,proto3" json:"lastSequenceNumber,omitempty"`
 }
 
-func (x *FollowerSuspected) Reset() {
-	*x = FollowerSuspected{}
+func (x *StateTransferRequested) Reset() {
+	*x = StateTransferRequested{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_protocol_messages_proto_msgTypes[8]
+		mi := &file_protocol_messages_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *FollowerSuspected) String() string {
+func (x *StateTransferRequested) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FollowerSuspected) ProtoMessage() {}
+func (*StateTransferRequested) ProtoMessage() {}
 
-func (x *FollowerSuspected) ProtoReflect() protoreflect.Message {
-	mi := &file_protocol_messages_proto_msgTypes[8]
+func (x *StateTransferRequested) ProtoReflect() protoreflect.Message {
+	mi := &file_protocol_messages_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -648,21 +1203,28 @@ func (x *FollowerSuspected) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FollowerSuspected.ProtoReflect.Descriptor instead.
-func (*FollowerSuspected) Descriptor() ([]byte, []int) {
-	return file_protocol_messages_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use StateTransferRequested.ProtoReflect.Descriptor instead.
+func (*StateTransferRequested) Descriptor() ([]byte, []int) {
+	return file_protocol_messages_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *FollowerSuspected) GetLeaderId() uint32 {
+func (x *StateTransferRequested) GetRequestingNodeId() uint32 {
 	if x != nil {
-		return x.LeaderId
+		return x.RequestingNodeId
 	}
 	return 0
 }
 
-func (x *FollowerSuspected) GetFollowerId() uint32 {
+func (x *StateTransferRequested) GetReceivingNodeId() uint32 {
 	if x != nil {
-		return x.FollowerId
+		return x.ReceivingNodeId
+	}
+	return 0
+}
+
+func (x *StateTransferRequested) GetLastSequenceNumber() uint64 {
+	if x != nil {
+		return x.LastSequenceNumber
 	}
 	return 0
 }
@@ -690,75 +1252,158 @@ var file_protocol_messages_proto_rawDesc = []byte{
 	0x63, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x44, 0x61, 0x74, 0x61, 0x22, 0x30, 0x0a, 0x0a, 0x44, 0x41,
 	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x22, 0x0a, 0x0c, 0x72, 0x65, 0x73, 0x70,
 	0x6f, 0x6e, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c,
-	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x22, 0x5a, 0x0a, 0x0a,
-	0x43, 0x75, 0x73, 0x74, 0x6f, 0x6d, 0x44, 0x61, 0x74, 0x61, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x28,
-	0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67,
-	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41,
-	0x6e, 0x79, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x6b, 0x0a, 0x13, 0x56, 0x6f, 0x74, 0x65,
-	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12,
-	0x2a, 0x0a, 0x10, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64,
-	0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x72, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x72,
-	0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e,
-	0x6f, 0x64, 0x65, 0x49, 0x64, 0x22, 0x76, 0x0a, 0x0c, 0x56, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x63,
-	0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x4e,
-	0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x76, 0x6f, 0x74,
-	0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x76, 0x6f, 0x74,
-	0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b,
-	0x76, 0x6f, 0x74, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x76,
-	0x6f, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
-	0x52, 0x0b, 0x76, 0x6f, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x65, 0x64, 0x22, 0x82, 0x01,
-	0x0a, 0x12, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63,
-	0x61, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x49, 0x64,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x49, 0x64,
-	0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64,
-	0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69,
-	0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x0e, 0x6c, 0x6f,
-	0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x03, 0x52, 0x0e, 0x6c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4e, 0x75, 0x6d, 0x62,
-	0x65, 0x72, 0x22, 0x81, 0x01, 0x0a, 0x11, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x43,
-	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64,
+	0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x54, 0x79, 0x70, 0x65, 0x22, 0x9a, 0x01, 0x0a,
+	0x09, 0x48, 0x61, 0x6e, 0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f,
+	0x64, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65,
+	0x49, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x4e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x37, 0x0a, 0x10, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74, 0x65, 0x64, 0x41, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x41, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x10, 0x73, 0x75, 0x70, 0x70, 0x6f, 0x72, 0x74,
+	0x65, 0x64, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x5c, 0x0a, 0x0c, 0x48, 0x61, 0x6e,
+	0x64, 0x73, 0x68, 0x61, 0x6b, 0x65, 0x41, 0x63, 0x6b, 0x12, 0x1a, 0x0a, 0x08, 0x61, 0x63, 0x63,
+	0x65, 0x70, 0x74, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x61, 0x63, 0x63,
+	0x65, 0x70, 0x74, 0x65, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12,
+	0x16, 0x0a, 0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x22, 0x5a, 0x0a, 0x0a, 0x43, 0x75, 0x73, 0x74, 0x6f,
+	0x6d, 0x44, 0x61, 0x74, 0x61, 0x12, 0x22, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x28, 0x0a, 0x04, 0x64, 0x61, 0x74,
+	0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x41, 0x6e, 0x79, 0x52, 0x04, 0x64,
+	0x61, 0x74, 0x61, 0x22, 0x7f, 0x0a, 0x13, 0x56, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x2a, 0x0a, 0x10, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x67,
+	0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64,
+	0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x72, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
+	0x74, 0x65, 0x72, 0x6d, 0x22, 0x76, 0x0a, 0x0c, 0x56, 0x6f, 0x74, 0x65, 0x52, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x64, 0x12, 0x22, 0x0a, 0x0c, 0x76, 0x6f, 0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f,
+	0x64, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x76, 0x6f, 0x74, 0x69,
+	0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x76, 0x6f, 0x74, 0x65,
+	0x64, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x76,
+	0x6f, 0x74, 0x65, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x76, 0x6f,
+	0x74, 0x65, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0b, 0x76, 0x6f, 0x74, 0x65, 0x47, 0x72, 0x61, 0x6e, 0x74, 0x65, 0x64, 0x22, 0x82, 0x01, 0x0a,
+	0x12, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x49, 0x64, 0x12,
+	0x28, 0x0a, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65,
+	0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76,
+	0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x0e, 0x6c, 0x6f, 0x67,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0e, 0x6c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x22, 0x81, 0x01, 0x0a, 0x11, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x43, 0x6f,
+	0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65,
+	0x72, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67,
+	0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x26, 0x0a,
+	0x0e, 0x6c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x6c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4e,
+	0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x59, 0x0a, 0x0f, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x53,
+	0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x10, 0x73, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x69,
+	0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10,
+	0x73, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64,
+	0x22, 0x4f, 0x0a, 0x11, 0x46, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x53, 0x75, 0x73, 0x70,
+	0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x49,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x49,
+	0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x49, 0x64, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x49,
+	0x64, 0x22, 0x96, 0x01, 0x0a, 0x12, 0x50, 0x72, 0x65, 0x50, 0x72, 0x65, 0x70, 0x61, 0x72, 0x65,
+	0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64,
 	0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x65, 0x61, 0x64,
 	0x65, 0x72, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e,
 	0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x72,
-	0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x26,
-	0x0a, 0x0e, 0x6c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x6c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x59, 0x0a, 0x0f, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x53, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61,
-	0x64, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x65, 0x61,
-	0x64, 0x65, 0x72, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x10, 0x73, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74,
-	0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52,
-	0x10, 0x73, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49,
-	0x64, 0x22, 0x4f, 0x0a, 0x11, 0x46, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x53, 0x75, 0x73,
-	0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x08, 0x6c, 0x65, 0x61, 0x64, 0x65, 0x72,
-	0x49, 0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x49, 0x64,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x66, 0x6f, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x72,
-	0x49, 0x64, 0x2a, 0xbc, 0x01, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x54, 0x79,
-	0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x44, 0x41, 0x5f, 0x52, 0x45, 0x53, 0x50, 0x4f, 0x4e, 0x53,
-	0x45, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x45, 0x41, 0x52, 0x54, 0x42, 0x45, 0x41, 0x54,
-	0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x56, 0x4f, 0x54, 0x45, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x45,
-	0x53, 0x54, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44, 0x10, 0x02, 0x12, 0x11, 0x0a,
-	0x0d, 0x56, 0x4f, 0x54, 0x45, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44, 0x10, 0x03,
-	0x12, 0x18, 0x0a, 0x14, 0x4c, 0x4f, 0x47, 0x5f, 0x45, 0x4e, 0x54, 0x52, 0x59, 0x5f, 0x52, 0x45,
-	0x50, 0x4c, 0x49, 0x43, 0x41, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x17, 0x0a, 0x13, 0x4c, 0x4f,
-	0x47, 0x5f, 0x45, 0x4e, 0x54, 0x52, 0x59, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54, 0x54, 0x45,
-	0x44, 0x10, 0x05, 0x12, 0x14, 0x0a, 0x10, 0x4c, 0x45, 0x41, 0x44, 0x45, 0x52, 0x5f, 0x53, 0x55,
-	0x53, 0x50, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10, 0x06, 0x12, 0x16, 0x0a, 0x12, 0x46, 0x4f, 0x4c,
-	0x4c, 0x4f, 0x57, 0x45, 0x52, 0x5f, 0x53, 0x55, 0x53, 0x50, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10,
-	0x07, 0x2a, 0x8a, 0x01, 0x0a, 0x0a, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x14, 0x0a, 0x10, 0x4e, 0x4f, 0x4f, 0x50, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f,
-	0x54, 0x59, 0x50, 0x45, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10, 0x48, 0x41, 0x4c, 0x54, 0x5f, 0x41,
-	0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10, 0x01, 0x12, 0x15, 0x0a, 0x11,
-	0x50, 0x41, 0x55, 0x53, 0x45, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50,
-	0x45, 0x10, 0x02, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x54, 0x4f, 0x50, 0x5f, 0x41, 0x43, 0x54, 0x49,
-	0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10, 0x03, 0x12, 0x23, 0x0a, 0x1f, 0x52, 0x45, 0x53,
-	0x45, 0x4e, 0x44, 0x5f, 0x4c, 0x41, 0x53, 0x54, 0x5f, 0x4d, 0x45, 0x53, 0x53, 0x41, 0x47, 0x45,
-	0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10, 0x04, 0x42, 0x12,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x12,
+	0x0a, 0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x76, 0x69,
+	0x65, 0x77, 0x12, 0x26, 0x0a, 0x0e, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75,
+	0x6d, 0x62, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x73, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x9d, 0x01, 0x0a, 0x0f, 0x50,
+	0x72, 0x65, 0x70, 0x61, 0x72, 0x65, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x24,
+	0x0a, 0x0d, 0x73, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x73, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x4e, 0x6f,
+	0x64, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e,
+	0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x72,
+	0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x12,
+	0x0a, 0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x76, 0x69,
+	0x65, 0x77, 0x12, 0x26, 0x0a, 0x0e, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75,
+	0x6d, 0x62, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x73, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x9c, 0x01, 0x0a, 0x0e, 0x43,
+	0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x24, 0x0a,
+	0x0d, 0x73, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0d, 0x73, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64,
+	0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67,
+	0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0f, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x76, 0x69, 0x65, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x76, 0x69, 0x65,
+	0x77, 0x12, 0x26, 0x0a, 0x0e, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x73, 0x65, 0x71, 0x75, 0x65,
+	0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x22, 0x73, 0x0a, 0x11, 0x56, 0x69, 0x65,
+	0x77, 0x43, 0x68, 0x61, 0x6e, 0x67, 0x65, 0x53, 0x74, 0x61, 0x72, 0x74, 0x65, 0x64, 0x12, 0x16,
+	0x0a, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06,
+	0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x2c, 0x0a, 0x11, 0x73, 0x75, 0x73, 0x70, 0x65, 0x63,
+	0x74, 0x65, 0x64, 0x4c, 0x65, 0x61, 0x64, 0x65, 0x72, 0x49, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x11, 0x73, 0x75, 0x73, 0x70, 0x65, 0x63, 0x74, 0x65, 0x64, 0x4c, 0x65, 0x61, 0x64,
+	0x65, 0x72, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x6e, 0x65, 0x77, 0x56, 0x69, 0x65, 0x77, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x6e, 0x65, 0x77, 0x56, 0x69, 0x65, 0x77, 0x22, 0x53,
+	0x0a, 0x11, 0x43, 0x68, 0x65, 0x63, 0x6b, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x52, 0x65, 0x61, 0x63,
+	0x68, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x0e, 0x73,
+	0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0e, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d,
+	0x62, 0x65, 0x72, 0x22, 0x9e, 0x01, 0x0a, 0x16, 0x53, 0x74, 0x61, 0x74, 0x65, 0x54, 0x72, 0x61,
+	0x6e, 0x73, 0x66, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x65, 0x64, 0x12, 0x2a,
+	0x0a, 0x10, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65,
+	0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x10, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x0f, 0x72, 0x65,
+	0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f, 0x64, 0x65, 0x49, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0d, 0x52, 0x0f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x69, 0x6e, 0x67, 0x4e, 0x6f,
+	0x64, 0x65, 0x49, 0x64, 0x12, 0x2e, 0x0a, 0x12, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x12, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x4e, 0x75,
+	0x6d, 0x62, 0x65, 0x72, 0x2a, 0xdf, 0x02, 0x0a, 0x0b, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x44, 0x41, 0x5f, 0x52, 0x45, 0x53, 0x50, 0x4f,
+	0x4e, 0x53, 0x45, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x45, 0x41, 0x52, 0x54, 0x42, 0x45,
+	0x41, 0x54, 0x10, 0x01, 0x12, 0x19, 0x0a, 0x15, 0x56, 0x4f, 0x54, 0x45, 0x5f, 0x52, 0x45, 0x51,
+	0x55, 0x45, 0x53, 0x54, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44, 0x10, 0x02, 0x12,
+	0x11, 0x0a, 0x0d, 0x56, 0x4f, 0x54, 0x45, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44,
+	0x10, 0x03, 0x12, 0x18, 0x0a, 0x14, 0x4c, 0x4f, 0x47, 0x5f, 0x45, 0x4e, 0x54, 0x52, 0x59, 0x5f,
+	0x52, 0x45, 0x50, 0x4c, 0x49, 0x43, 0x41, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x17, 0x0a, 0x13,
+	0x4c, 0x4f, 0x47, 0x5f, 0x45, 0x4e, 0x54, 0x52, 0x59, 0x5f, 0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54,
+	0x54, 0x45, 0x44, 0x10, 0x05, 0x12, 0x14, 0x0a, 0x10, 0x4c, 0x45, 0x41, 0x44, 0x45, 0x52, 0x5f,
+	0x53, 0x55, 0x53, 0x50, 0x45, 0x43, 0x54, 0x45, 0x44, 0x10, 0x06, 0x12, 0x16, 0x0a, 0x12, 0x46,
+	0x4f, 0x4c, 0x4c, 0x4f, 0x57, 0x45, 0x52, 0x5f, 0x53, 0x55, 0x53, 0x50, 0x45, 0x43, 0x54, 0x45,
+	0x44, 0x10, 0x07, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x41, 0x4e, 0x44, 0x53, 0x48, 0x41, 0x4b, 0x45,
+	0x10, 0x08, 0x12, 0x18, 0x0a, 0x14, 0x50, 0x52, 0x45, 0x5f, 0x50, 0x52, 0x45, 0x50, 0x41, 0x52,
+	0x45, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44, 0x10, 0x09, 0x12, 0x14, 0x0a, 0x10,
+	0x50, 0x52, 0x45, 0x50, 0x41, 0x52, 0x45, 0x5f, 0x52, 0x45, 0x43, 0x45, 0x49, 0x56, 0x45, 0x44,
+	0x10, 0x0a, 0x12, 0x13, 0x0a, 0x0f, 0x43, 0x4f, 0x4d, 0x4d, 0x49, 0x54, 0x5f, 0x52, 0x45, 0x43,
+	0x45, 0x49, 0x56, 0x45, 0x44, 0x10, 0x0b, 0x12, 0x17, 0x0a, 0x13, 0x56, 0x49, 0x45, 0x57, 0x5f,
+	0x43, 0x48, 0x41, 0x4e, 0x47, 0x45, 0x5f, 0x53, 0x54, 0x41, 0x52, 0x54, 0x45, 0x44, 0x10, 0x0c,
+	0x12, 0x16, 0x0a, 0x12, 0x43, 0x48, 0x45, 0x43, 0x4b, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x5f, 0x52,
+	0x45, 0x41, 0x43, 0x48, 0x45, 0x44, 0x10, 0x0d, 0x12, 0x1c, 0x0a, 0x18, 0x53, 0x54, 0x41, 0x54,
+	0x45, 0x5f, 0x54, 0x52, 0x41, 0x4e, 0x53, 0x46, 0x45, 0x52, 0x5f, 0x52, 0x45, 0x51, 0x55, 0x45,
+	0x53, 0x54, 0x45, 0x44, 0x10, 0x0e, 0x2a, 0x8a, 0x01, 0x0a, 0x0a, 0x41, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x54, 0x79, 0x70, 0x65, 0x12, 0x14, 0x0a, 0x10, 0x4e, 0x4f, 0x4f, 0x50, 0x5f, 0x41, 0x43,
+	0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10, 0x00, 0x12, 0x14, 0x0a, 0x10, 0x48,
+	0x41, 0x4c, 0x54, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10,
+	0x01, 0x12, 0x15, 0x0a, 0x11, 0x50, 0x41, 0x55, 0x53, 0x45, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f,
+	0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10, 0x02, 0x12, 0x14, 0x0a, 0x10, 0x53, 0x54, 0x4f, 0x50,
+	0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50, 0x45, 0x10, 0x03, 0x12, 0x23,
+	0x0a, 0x1f, 0x52, 0x45, 0x53, 0x45, 0x4e, 0x44, 0x5f, 0x4c, 0x41, 0x53, 0x54, 0x5f, 0x4d, 0x45,
+	0x53, 0x53, 0x41, 0x47, 0x45, 0x5f, 0x41, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x54, 0x59, 0x50,
+	0x45, 0x10, 0x04, 0x32, 0x39, 0x0a, 0x14, 0x44, 0x69, 0x73, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
+	0x65, 0x64, 0x41, 0x73, 0x73, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x74, 0x12, 0x21, 0x0a, 0x07, 0x43,
+	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x12, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x1a, 0x08, 0x2e, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x28, 0x01, 0x30, 0x01, 0x42, 0x12,
 	0x5a, 0x10, 0x6e, 0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
 	0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
@@ -776,32 +1421,43 @@ func file_protocol_messages_proto_rawDescGZIP() []byte {
 }
 
 var file_protocol_messages_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_protocol_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_protocol_messages_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_protocol_messages_proto_goTypes = []interface{}{
-	(MessageType)(0),            // 0: MessageType
-	(ActionType)(0),             // 1: ActionType
-	(*Message)(nil),             // 2: Message
-	(*DAResponse)(nil),          // 3: DAResponse
-	(*CustomData)(nil),          // 4: CustomData
-	(*VoteRequestReceived)(nil), // 5: VoteRequestReceived
-	(*VoteReceived)(nil),        // 6: VoteReceived
-	(*LogEntryReplicated)(nil),  // 7: LogEntryReplicated
-	(*LogEntryCommitted)(nil),   // 8: LogEntryCommitted
-	(*LeaderSuspected)(nil),     // 9: LeaderSuspected
-	(*FollowerSuspected)(nil),   // 10: FollowerSuspected
-	(*anypb.Any)(nil),           // 11: google.protobuf.Any
+	(MessageType)(0),               // 0: MessageType
+	(ActionType)(0),                // 1: ActionType
+	(*Message)(nil),                // 2: Message
+	(*DAResponse)(nil),             // 3: DAResponse
+	(*Handshake)(nil),              // 4: Handshake
+	(*HandshakeAck)(nil),           // 5: HandshakeAck
+	(*CustomData)(nil),             // 6: CustomData
+	(*VoteRequestReceived)(nil),    // 7: VoteRequestReceived
+	(*VoteReceived)(nil),           // 8: VoteReceived
+	(*LogEntryReplicated)(nil),     // 9: LogEntryReplicated
+	(*LogEntryCommitted)(nil),      // 10: LogEntryCommitted
+	(*LeaderSuspected)(nil),        // 11: LeaderSuspected
+	(*FollowerSuspected)(nil),      // 12: FollowerSuspected
+	(*PrePrepareReceived)(nil),     // 13: PrePrepareReceived
+	(*PrepareReceived)(nil),        // 14: PrepareReceived
+	(*CommitReceived)(nil),         // 15: CommitReceived
+	(*ViewChangeStarted)(nil),      // 16: ViewChangeStarted
+	(*CheckpointReached)(nil),      // 17: CheckpointReached
+	(*StateTransferRequested)(nil), // 18: StateTransferRequested
+	(*anypb.Any)(nil),              // 19: google.protobuf.Any
 }
 var file_protocol_messages_proto_depIdxs = []int32{
 	0,  // 0: Message.messageType:type_name -> MessageType
 	1,  // 1: Message.actionType:type_name -> ActionType
-	11, // 2: Message.messageObject:type_name -> google.protobuf.Any
-	4,  // 3: Message.customData:type_name -> CustomData
-	11, // 4: CustomData.data:type_name -> google.protobuf.Any
-	5,  // [5:5] is the sub-list for method output_type
-	5,  // [5:5] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	19, // 2: Message.messageObject:type_name -> google.protobuf.Any
+	6,  // 3: Message.customData:type_name -> CustomData
+	1,  // 4: Handshake.supportedActions:type_name -> ActionType
+	19, // 5: CustomData.data:type_name -> google.protobuf.Any
+	2,  // 6: DistributedAssistant.Connect:input_type -> Message
+	2,  // 7: DistributedAssistant.Connect:output_type -> Message
+	7,  // [7:8] is the sub-list for method output_type
+	6,  // [6:7] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
 }
 
 func init() { file_protocol_messages_proto_init() }
@@ -835,7 +1491,7 @@ func file_protocol_messages_proto_init() {
 			}
 		}
 		file_protocol_messages_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CustomData); i {
+			switch v := v.(*Handshake); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -847,7 +1503,7 @@ func file_protocol_messages_proto_init() {
 			}
 		}
 		file_protocol_messages_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VoteRequestReceived); i {
+			switch v := v.(*HandshakeAck); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -859,7 +1515,7 @@ func file_protocol_messages_proto_init() {
 			}
 		}
 		file_protocol_messages_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*VoteReceived); i {
+			switch v := v.(*CustomData); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -871,7 +1527,7 @@ func file_protocol_messages_proto_init() {
 			}
 		}
 		file_protocol_messages_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LogEntryReplicated); i {
+			switch v := v.(*VoteRequestReceived); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -883,7 +1539,7 @@ func file_protocol_messages_proto_init() {
 			}
 		}
 		file_protocol_messages_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LogEntryCommitted); i {
+			switch v := v.(*VoteReceived); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -895,7 +1551,7 @@ func file_protocol_messages_proto_init() {
 			}
 		}
 		file_protocol_messages_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*LeaderSuspected); i {
+			switch v := v.(*LogEntryReplicated); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -907,6 +1563,30 @@ func file_protocol_messages_proto_init() {
 			}
 		}
 		file_protocol_messages_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LogEntryCommitted); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocol_messages_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LeaderSuspected); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocol_messages_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*FollowerSuspected); i {
 			case 0:
 				return &v.state
@@ -918,6 +1598,78 @@ func file_protocol_messages_proto_init() {
 				return nil
 			}
 		}
+		file_protocol_messages_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PrePrepareReceived); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocol_messages_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PrepareReceived); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocol_messages_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CommitReceived); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocol_messages_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ViewChangeStarted); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocol_messages_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CheckpointReached); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocol_messages_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*StateTransferRequested); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	file_protocol_messages_proto_msgTypes[0].OneofWrappers = []interface{}{}
 	type x struct{}
@@ -926,9 +1678,9 @@ func file_protocol_messages_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_protocol_messages_proto_rawDesc,
 			NumEnums:      2,
-			NumMessages:   9,
+			NumMessages:   17,
 			NumExtensions: 0,
-			NumServices:   0,
+			NumServices:   1,
 		},
 		GoTypes:           file_protocol_messages_proto_goTypes,
 		DependencyIndexes: file_protocol_messages_proto_depIdxs,
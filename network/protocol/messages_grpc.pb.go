@@ -0,0 +1,141 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.23.4
+// source: protocol/messages.proto
+
+package protocol
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	DistributedAssistant_Connect_FullMethodName = "/DistributedAssistant/Connect"
+)
+
+// DistributedAssistantClient is the client API for DistributedAssistant service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DistributedAssistantClient interface {
+	Connect(ctx context.Context, opts ...grpc.CallOption) (DistributedAssistant_ConnectClient, error)
+}
+
+type distributedAssistantClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDistributedAssistantClient(cc grpc.ClientConnInterface) DistributedAssistantClient {
+	return &distributedAssistantClient{cc}
+}
+
+func (c *distributedAssistantClient) Connect(ctx context.Context, opts ...grpc.CallOption) (DistributedAssistant_ConnectClient, error) {
+	stream, err := c.cc.NewStream(ctx, &DistributedAssistant_ServiceDesc.Streams[0], DistributedAssistant_Connect_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &distributedAssistantConnectClient{stream}
+	return x, nil
+}
+
+type DistributedAssistant_ConnectClient interface {
+	Send(*Message) error
+	Recv() (*Message, error)
+	grpc.ClientStream
+}
+
+type distributedAssistantConnectClient struct {
+	grpc.ClientStream
+}
+
+func (x *distributedAssistantConnectClient) Send(m *Message) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *distributedAssistantConnectClient) Recv() (*Message, error) {
+	m := new(Message)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DistributedAssistantServer is the server API for DistributedAssistant service.
+// All implementations must embed UnimplementedDistributedAssistantServer
+// for forward compatibility
+type DistributedAssistantServer interface {
+	Connect(DistributedAssistant_ConnectServer) error
+	mustEmbedUnimplementedDistributedAssistantServer()
+}
+
+// UnimplementedDistributedAssistantServer must be embedded to have forward compatible implementations.
+type UnimplementedDistributedAssistantServer struct {
+}
+
+func (UnimplementedDistributedAssistantServer) Connect(DistributedAssistant_ConnectServer) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedDistributedAssistantServer) mustEmbedUnimplementedDistributedAssistantServer() {}
+
+// UnsafeDistributedAssistantServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DistributedAssistantServer will
+// result in compilation errors.
+type UnsafeDistributedAssistantServer interface {
+	mustEmbedUnimplementedDistributedAssistantServer()
+}
+
+func RegisterDistributedAssistantServer(s grpc.ServiceRegistrar, srv DistributedAssistantServer) {
+	s.RegisterService(&DistributedAssistant_ServiceDesc, srv)
+}
+
+func _DistributedAssistant_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DistributedAssistantServer).Connect(&distributedAssistantConnectServer{stream})
+}
+
+type DistributedAssistant_ConnectServer interface {
+	Send(*Message) error
+	Recv() (*Message, error)
+	grpc.ServerStream
+}
+
+type distributedAssistantConnectServer struct {
+	grpc.ServerStream
+}
+
+func (x *distributedAssistantConnectServer) Send(m *Message) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *distributedAssistantConnectServer) Recv() (*Message, error) {
+	m := new(Message)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DistributedAssistant_ServiceDesc is the grpc.ServiceDesc for DistributedAssistant service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DistributedAssistant_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "DistributedAssistant",
+	HandlerType: (*DistributedAssistantServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _DistributedAssistant_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "protocol/messages.proto",
+}
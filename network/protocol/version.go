@@ -0,0 +1,5 @@
+package protocol
+
+// Version is the version of the protocol spoken by this DA and its client. Increment it for changes to
+// messages.proto that older nodes or DAs cannot handle.
+const Version uint32 = 1
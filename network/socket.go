@@ -2,158 +2,485 @@ package network
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/metrics"
 	"github.com/FatProteins/master-thesis-code/network/protocol"
 	"github.com/FatProteins/master-thesis-code/util"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"io"
 	"net"
-	"os"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 var logger = daLogger.NewLogger("network")
 
+// MinProtocolVersion is the oldest protocol version of nodes the DA serves.
+const MinProtocolVersion uint32 = 1
+
+const (
+	minListenBackoff = 100 * time.Millisecond
+	maxListenBackoff = 5 * time.Second
+)
+
+// NetworkLayer accepts connections of instrumented nodes on a transport. Every connection is served by its own
+// goroutine, while all messages are handed to the same channel. Responses are written back to the connection the
+// message was received on. If the transport cannot be listened on, the network layer retries with backoff instead
+// of failing.
 type NetworkLayer struct {
-	*net.UnixConn
-	localAddr      *net.UnixAddr
-	messagePool    *util.Pool[protocol.Message]
+	transport      Transport
+	listener       Listener
+	envelopePool   *util.Pool[envelope]
 	handleChan     chan<- Message
 	respChan       <-chan Message
-	unixSocketPath string
-	resetConn      atomic.Bool
+	maxMessageSize int
+	closed         atomic.Bool
+	connsMutex     sync.Mutex
+	conns          map[*peerConn]struct{}
+	state          ConnState
+	stateSince     time.Time
+	heartbeatFunc  func(node uint32)
+	lastConnId     atomic.Uint64
+}
+
+// peerConn is the connection to a single instrumented node.
+type peerConn struct {
+	Conn
+	id           uint64
+	networkLayer *NetworkLayer
+	peer         Peer
+	writeMutex   sync.Mutex
+	closed       atomic.Bool
+	inFlight     atomic.Int64
+	state        ConnState
+	stateSince   time.Time
+	// responseBuffer is reused to marshal every response, it is guarded by the write mutex.
+	responseBuffer []byte
+	resetConn      func()
+	// sequence numbers the messages handed to the processor, it is only used by the reader goroutine.
+	sequence uint64
+	// released is the sequence number of the last message freed by the processor, deferredDefaults the sequence
+	// numbers after which default responses are due. Both are guarded by the order mutex.
+	orderMutex       sync.Mutex
+	released         uint64
+	deferredDefaults []uint64
+	// reportedNode is the node an anonymous peer reports events of, see Message.AttributeTo. It is written by the
+	// processor, reported is set once it is.
+	reportedNode atomic.Uint32
+	reported     atomic.Bool
+}
+
+// defaultResponse answers messages that cannot be handled. It carries an empty payload, since a response without
+// fields marshals to no bytes, which a node reads as end of file on a unix packet socket.
+var defaultResponse = &protocol.Message{MessageType: protocol.MessageType_DA_RESPONSE, MessageObject: &anypb.Any{}}
+
+func NewNetworkLayer(handleChan chan<- Message, respChan <-chan Message, transport Transport, maxMessageSize int) (*NetworkLayer, error) {
+	if maxMessageSize <= 0 {
+		return nil, fmt.Errorf("maximum message size must be positive, but is %d", maxMessageSize)
+	}
+
+	return &NetworkLayer{
+		transport:      transport,
+		envelopePool:   util.NewPool[envelope](),
+		handleChan:     handleChan,
+		respChan:       respChan,
+		maxMessageSize: maxMessageSize,
+		conns:          make(map[*peerConn]struct{}),
+	}, nil
 }
 
-func NewNetworkLayer(handleChan chan<- Message, respChan <-chan Message, localAddr *net.UnixAddr, unixSocketPath string) (*NetworkLayer, error) {
+// OnHeartbeat sets the function called for every heartbeat with the node that sent it: the node of the handshake, or
+// for anonymous peers the node their messages are attributed to. Heartbeats of an anonymous peer are ignored until
+// its first message is attributed. Heartbeats are not queued but answered at once with the default response, in
+// order with the responses of the other messages, so they keep arriving while the DA performs an action. Must be
+// called before RunAsync.
+func (networkLayer *NetworkLayer) OnHeartbeat(heartbeatFunc func(node uint32)) {
+	networkLayer.heartbeatFunc = heartbeatFunc
+}
 
-	//connection, err := net.DialUnix("unixgram", nil, remoteAddr)
-	//if err != nil {
-	//	return nil, err
-	//}
+func (networkLayer *NetworkLayer) RunAsync(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		_ = networkLayer.Close()
+	}()
 
-	resetConn := atomic.Bool{}
-	resetConn.Store(true)
-	return &NetworkLayer{localAddr: localAddr, messagePool: util.NewPool[protocol.Message](), handleChan: handleChan, respChan: respChan, unixSocketPath: unixSocketPath, resetConn: resetConn}, nil
+	go networkLayer.listenLoop(ctx)
 }
 
-func (networkLayer *NetworkLayer) ResetConn() {
-	if networkLayer.UnixConn != nil {
-		networkLayer.UnixConn.Close()
-		err := os.Remove(networkLayer.unixSocketPath)
+// listenLoop listens on the transport and accepts connections. Whenever listening or accepting fails,
+// it waits with exponential backoff and listens again.
+func (networkLayer *NetworkLayer) listenLoop(ctx context.Context) {
+	backoff := minListenBackoff
+	for !networkLayer.closed.Load() {
+		listener, err := networkLayer.transport.Listen()
 		if err != nil {
-			panic(err)
+			metrics.ListenFailures.Inc()
+			logger.ErrorErr(err, "Failed to listen on '%s', retrying in %s", networkLayer.transport.Address(), backoff.String())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = util.Min(2*backoff, maxListenBackoff)
+			continue
+		}
+
+		backoff = minListenBackoff
+		networkLayer.setListener(listener)
+		err = networkLayer.acceptLoop(ctx, listener)
+		networkLayer.setListener(nil)
+		_ = listener.Close()
+		if networkLayer.closed.Load() {
+			return
 		}
+
+		metrics.ListenFailures.Inc()
+		logger.ErrorErr(err, "Failed to accept connections on '%s', listening again", networkLayer.transport.Address())
 	}
+}
 
-	listener, err := net.ListenUnix("unix", networkLayer.localAddr)
-	if err != nil {
-		panic("failed to listen to unix socket")
+func (networkLayer *NetworkLayer) setListener(listener Listener) {
+	networkLayer.connsMutex.Lock()
+	defer networkLayer.connsMutex.Unlock()
+
+	networkLayer.listener = listener
+	networkLayer.updateState()
+}
+
+func (networkLayer *NetworkLayer) acceptLoop(ctx context.Context, listener Listener) error {
+	for {
+		connection, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		conn := &peerConn{
+			Conn:         connection,
+			id:           networkLayer.lastConnId.Add(1),
+			networkLayer: networkLayer,
+			state:        StateConnected,
+			stateSince:   time.Now(),
+		}
+		conn.resetConn = conn.reset
+		networkLayer.connsMutex.Lock()
+		networkLayer.conns[conn] = struct{}{}
+		networkLayer.updateState()
+		networkLayer.connsMutex.Unlock()
+
+		go conn.run(ctx)
 	}
+}
 
-	connection, err := listener.AcceptUnix()
-	if err != nil {
-		panic("failed to connect unix socket")
+// Peers returns the nodes that are currently connected.
+func (networkLayer *NetworkLayer) Peers() []Peer {
+	networkLayer.connsMutex.Lock()
+	defer networkLayer.connsMutex.Unlock()
+
+	peers := make([]Peer, 0, len(networkLayer.conns))
+	for conn := range networkLayer.conns {
+		if conn.state == StateConnected {
+			peers = append(peers, conn.peer)
+		}
 	}
-	networkLayer.UnixConn = connection
+	return peers
 }
 
-func (networkLayer *NetworkLayer) RunAsync(ctx context.Context) {
-	go func() {
-		messageBuffer := make([]byte, 4096*10)
-		for {
-			if networkLayer.resetConn.Load() {
-				networkLayer.ResetConn()
-				networkLayer.resetConn.Store(false)
+func (conn *peerConn) run(ctx context.Context) {
+	networkLayer := conn.networkLayer
+	defer conn.close()
+
+	// The buffer is reused for every message, unmarshalling copies all data out of it.
+	messageBuffer := make([]byte, networkLayer.maxMessageSize)
+	first := true
+	for {
+		messageBytes, err := conn.ReadMessage(messageBuffer)
+		received := time.Now()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return
+			default:
 			}
-			bytesRead, _, err := networkLayer.ReadFromUnix(messageBuffer)
-			if err != nil {
-				//logger.ErrorErr(err, "Failed to read message from unix socket")
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					continue
-				}
+
+			if errors.Is(err, ErrMessageTooLarge) {
+				logger.ErrorErr(err, "Dropped oversized message from %s", conn.peer.String())
+				metrics.OversizedMessages.Inc()
+				conn.respondDefault()
+				continue
 			}
 
-			logger.Debug("Read msg of length %d", bytesRead)
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				logger.Info("Connection to %s closed", conn.peer.String())
+			} else {
+				logger.ErrorErr(err, "Failed to read message from %s, closing connection", conn.peer.String())
+			}
+			return
+		}
 
-			messageBuffer = messageBuffer[:bytesRead]
-			protoMsg := networkLayer.messagePool.Get()
+		if logger.Enabled(daLogger.LevelDebug) {
+			logger.Debug("Read msg of length %d", len(messageBytes))
+		}
+
+		envelope := networkLayer.envelopePool.Get()
+		err = envelope.unmarshal(messageBytes)
+		if err != nil {
+			logger.ErrorErr(err, "Failed to unmarshal message of length %d from %s", len(messageBytes), conn.peer.String())
+			networkLayer.envelopePool.Put(envelope)
+			conn.respondDefault()
+			continue
+		}
 
-			err = proto.Unmarshal(messageBuffer, protoMsg)
-			if err != nil {
-				//logger.ErrorErr(err, "Failed to unmarshal message")
-				select {
-				case <-ctx.Done():
+		if first {
+			first = false
+			if envelope.message.MessageType == protocol.MessageType_HANDSHAKE {
+				accepted := conn.handshake(&envelope.message)
+				networkLayer.envelopePool.Put(envelope)
+				if !accepted {
 					return
-				default:
-					continue
 				}
+				continue
 			}
+			logger.Info("Connection without handshake, serving anonymous peer")
+		}
 
-			select {
-			case <-ctx.Done():
-				return
-			case networkLayer.handleChan <- Message{
-				Message:  protoMsg,
-				response: networkLayer.messagePool.Get(),
-				closeFunc: func(message *protocol.Message) {
-					logger.Debug("Putting back msg to pool")
-					message.Reset()
-					networkLayer.messagePool.Put(message)
-					logger.Debug("Done back msg to pool")
-				},
-				respondFunc: func(response *protocol.Message) {
-					logger.Debug("Responding with response '%s'", response.String())
-					respBytes, err := proto.Marshal(response)
-					if err != nil {
-						logger.ErrorErr(err, "Failed to marshal DA response to bytes")
-						return
-					}
-
-					bytesWritten, err := networkLayer.Write(respBytes)
-					if err != nil {
-						logger.ErrorErr(err, "Failed to send DA response")
-						return
-					}
-
-					logger.Debug("Sent DA response with length %d", bytesWritten)
-				},
-				resetConnFunc: func() {
-					networkLayer.resetConn.Store(true)
-				},
-			}:
+		if envelope.message.MessageType == protocol.MessageType_HEARTBEAT {
+			networkLayer.envelopePool.Put(envelope)
+			metrics.HeartbeatsReceived.Inc()
+			if networkLayer.heartbeatFunc != nil {
+				node, known := conn.heartbeatNode()
+				if known {
+					networkLayer.heartbeatFunc(node)
+				} else {
+					logger.Debug("Ignoring heartbeat of %s that reported no event yet", conn.peer.String())
+				}
 			}
+			conn.respondDefault()
+			continue
 		}
-	}()
-	//
-	//go func() {
-	//	for {
-	//		select {
-	//		case <-ctx.Done():
-	//			return
-	//		case response := <-networkLayer.respChan:
-	//			respBytes, err := proto.Marshal(response)
-	//			if err != nil {
-	//				logger.ErrorErr(err, "Failed to marshal DA response to bytes")
-	//				response.FreeMessage()
-	//				continue
-	//			}
-	//
-	//			bytesWritten, err := networkLayer.Write(respBytes)
-	//			if err != nil {
-	//				logger.ErrorErr(err, "Failed to send DA response")
-	//				response.FreeMessage()
-	//				continue
-	//			}
-	//
-	//			logger.Debug("Sent DA response with length %d", bytesWritten)
-	//			response.FreeMessage()
-	//		}
-	//	}
-	//}()
+
+		conn.inFlight.Add(1)
+		conn.sequence++
+		envelope.sequence = conn.sequence
+		select {
+		case <-ctx.Done():
+			return
+		case networkLayer.handleChan <- Message{
+			Message:    &envelope.message,
+			response:   &envelope.response,
+			peer:       conn.peer,
+			received:   received,
+			envelope:   envelope,
+			generation: envelope.lease(),
+			owner:      conn,
+		}:
+		}
+	}
+}
+
+// handshake identifies the peer and acknowledges the handshake. It returns false if the node speaks an
+// incompatible protocol version, the connection must be closed then.
+func (conn *peerConn) handshake(message *protocol.Message) bool {
+	handshake, err := DecodePayload[*protocol.Handshake](message)
+	if err != nil {
+		logger.ErrorErr(err, "Failed to decode handshake, serving anonymous peer")
+		conn.respondHandshake(&protocol.HandshakeAck{Accepted: true, Version: protocol.Version})
+		return true
+	}
+
+	version := util.Max(handshake.Version, 1)
+	if version < MinProtocolVersion || version > protocol.Version {
+		reason := fmt.Sprintf("protocol version %d is not supported, the DA supports versions %d to %d", version, MinProtocolVersion, protocol.Version)
+		logger.Error("Rejecting node %d (%s): %s", handshake.NodeId, handshake.ProtocolName, reason)
+		metrics.RejectedHandshakes.Inc()
+		conn.respondHandshake(&protocol.HandshakeAck{Accepted: false, Version: protocol.Version, Reason: reason})
+		return false
+	}
+
+	// Peers and Status read the peer of every connection under the mutex. The reader goroutine of the connection is
+	// the only one writing it, so it reads its peer without the mutex.
+	conn.networkLayer.connsMutex.Lock()
+	conn.peer = Peer{
+		NodeId:           handshake.NodeId,
+		ProtocolName:     handshake.ProtocolName,
+		Identified:       true,
+		Version:          version,
+		SupportedActions: handshake.SupportedActions,
+	}
+	conn.networkLayer.connsMutex.Unlock()
+	logger.Info("Connected to %s speaking protocol version %d", conn.peer.String(), version)
+	conn.respondHandshake(&protocol.HandshakeAck{Accepted: true, Version: protocol.Version})
+	return true
+}
+
+func (conn *peerConn) respondHandshake(ack *protocol.HandshakeAck) {
+	messageObject, err := anypb.New(ack)
+	if err != nil {
+		metrics.ResponseMarshalErrors.Inc()
+		return
+	}
+
+	conn.respond(&protocol.Message{MessageType: protocol.MessageType_DA_RESPONSE, MessageObject: messageObject})
+}
+
+// reset closes the connection, e.g. because the node was stopped. The node connects again after its restart.
+func (conn *peerConn) reset() {
+	metrics.SocketResets.Inc()
+	logger.Info("Resetting connection to %s", conn.peer.String())
+	conn.close()
+}
+
+// close closes the connection and drains it: it stays known until all messages received on it are handled.
+func (conn *peerConn) close() {
+	if conn.closed.Swap(true) {
+		return
+	}
+	_ = conn.Close()
+
+	networkLayer := conn.networkLayer
+	networkLayer.connsMutex.Lock()
+	defer networkLayer.connsMutex.Unlock()
+	conn.state = StateDraining
+	conn.stateSince = time.Now()
+	conn.removeIfDrained()
+}
+
+// done is called when a message received on the connection is freed.
+func (conn *peerConn) done() {
+	if conn.inFlight.Add(-1) != 0 || !conn.closed.Load() {
+		return
+	}
+
+	conn.networkLayer.connsMutex.Lock()
+	defer conn.networkLayer.connsMutex.Unlock()
+	conn.removeIfDrained()
+}
+
+// removeIfDrained must be called with connsMutex held.
+func (conn *peerConn) removeIfDrained() {
+	if conn.inFlight.Load() == 0 {
+		delete(conn.networkLayer.conns, conn)
+	}
+	conn.networkLayer.updateState()
+}
+
+func (conn *peerConn) info() ConnectionInfo {
+	return ConnectionInfo{Id: conn.id, Peer: conn.peer, State: conn.state, Since: conn.stateSince, InFlight: conn.inFlight.Load()}
+}
+
+// respond marshals the response into the response buffer and writes it. Responses of closed connections are
+// dropped.
+func (conn *peerConn) respond(response *protocol.Message) {
+	if conn.closed.Load() {
+		logger.Debug("Dropping response for closed connection to %s", conn.peer.String())
+		metrics.DroppedResponses.Inc()
+		return
+	}
+
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+
+	var err error
+	conn.responseBuffer, err = proto.MarshalOptions{}.MarshalAppend(conn.responseBuffer[:0], response)
+	if err != nil {
+		logger.ErrorErr(err, "Failed to marshal DA response to bytes")
+		metrics.ResponseMarshalErrors.Inc()
+		return
+	}
+
+	err = conn.WriteMessage(conn.responseBuffer)
+	if err != nil {
+		logger.ErrorErr(err, "Failed to send DA response to %s", conn.peer.String())
+		return
+	}
+}
+
+// release puts the envelope of a handled message back into the pool, or poisons it in poison mode. Default
+// responses due after the message are written now, as its response was written before it was freed.
+func (conn *peerConn) release(envelope *envelope) {
+	conn.orderMutex.Lock()
+	conn.released = envelope.sequence
+	due := 0
+	for due < len(conn.deferredDefaults) && conn.deferredDefaults[due] <= conn.released {
+		due++
+	}
+	conn.deferredDefaults = conn.deferredDefaults[due:]
+	conn.orderMutex.Unlock()
+	for ; due > 0; due-- {
+		conn.respond(defaultResponse)
+	}
+
+	if poisonFreed.Load() {
+		envelope.poison()
+	} else {
+		conn.networkLayer.envelopePool.Put(envelope)
+	}
+	conn.done()
+}
+
+func (conn *peerConn) attribute(node uint32) {
+	conn.reportedNode.Store(node)
+	conn.reported.Store(true)
+}
+
+// heartbeatNode returns the node the heartbeats of the connection belong to. It is only called by the reader
+// goroutine, which writes the peer.
+func (conn *peerConn) heartbeatNode() (uint32, bool) {
+	if conn.peer.Identified {
+		return conn.peer.NodeId, true
+	}
+	if !conn.reported.Load() {
+		return 0, false
+	}
+	return conn.reportedNode.Load(), true
+}
+
+func (conn *peerConn) connId() uint64 {
+	return conn.id
+}
+
+func (conn *peerConn) stale() bool {
+	return conn.closed.Load()
+}
+
+func (conn *peerConn) resetFunc() func() {
+	return conn.resetConn
+}
+
+// respondDefault answers a message that could not be handled with an empty DA response, so the node does not wait
+// forever and requests and responses stay aligned. While earlier messages of the connection are not answered yet,
+// the response is deferred until the last of them is freed. It is only called by the reader goroutine.
+func (conn *peerConn) respondDefault() {
+	conn.orderMutex.Lock()
+	if conn.released != conn.sequence {
+		conn.deferredDefaults = append(conn.deferredDefaults, conn.sequence)
+		conn.orderMutex.Unlock()
+		return
+	}
+	conn.orderMutex.Unlock()
+	conn.respond(defaultResponse)
 }
 
 func (networkLayer *NetworkLayer) Close() error {
-	return networkLayer.UnixConn.Close()
+	networkLayer.closed.Store(true)
+
+	networkLayer.connsMutex.Lock()
+	listener := networkLayer.listener
+	conns := make([]*peerConn, 0, len(networkLayer.conns))
+	for conn := range networkLayer.conns {
+		conns = append(conns, conn)
+	}
+	networkLayer.connsMutex.Unlock()
+
+	var err error
+	if listener != nil {
+		err = listener.Close()
+	}
+	for _, conn := range conns {
+		conn.close()
+	}
+	return err
 }
@@ -0,0 +1,140 @@
+package network
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+)
+
+// TestPeersDuringHandshake reads the peers like the connections API while nodes identify themselves. Run with
+// -race, the handshake must not race with Peers and Status.
+func TestPeersDuringHandshake(t *testing.T) {
+	transport := testTransports(t)[0]
+	networkLayer := startNetworkLayer(t, transport.transport)
+
+	var done atomic.Bool
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for !done.Load() {
+			_ = networkLayer.Peers()
+			_ = networkLayer.Status()
+		}
+	}()
+
+	for nodeId := uint32(1); nodeId <= 5; nodeId++ {
+		connect(t, transport).handshake(nodeId)
+	}
+	done.Store(true)
+	readers.Wait()
+
+	identified := 0
+	for _, peer := range networkLayer.Peers() {
+		if peer.Identified {
+			identified++
+		}
+	}
+	if identified != 5 {
+		t.Errorf("%d peers identified, want 5", identified)
+	}
+}
+
+// startHeartbeatNetworkLayer runs a network layer handing the node of every heartbeat to the channel. Its processor
+// attributes every message to the node that received the vote, like the processor does for anonymous peers.
+func startHeartbeatNetworkLayer(t *testing.T, transport Transport) <-chan uint32 {
+	t.Helper()
+	handleChan := make(chan Message, 100)
+	networkLayer, err := NewNetworkLayer(handleChan, nil, transport, testMaxMessageSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	heartbeats := make(chan uint32, 100)
+	networkLayer.OnHeartbeat(func(node uint32) {
+		heartbeats <- node
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	networkLayer.RunAsync(ctx)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message := <-handleChan:
+				vote, err := DecodePayload[*protocol.VoteRequestReceived](message.Message)
+				if err == nil && !message.Peer().Identified {
+					message.AttributeTo(vote.ReceivingNodeId)
+				}
+				response := message.GetResponse()
+				response.Reset()
+				response.MessageType = protocol.MessageType_DA_RESPONSE
+				response.MessageObject = message.MessageObject
+				message.Respond()
+				message.FreeMessage()
+			}
+		}
+	}()
+	return heartbeats
+}
+
+func expectHeartbeats(t *testing.T, heartbeats <-chan uint32, expected ...uint32) {
+	t.Helper()
+	for _, node := range expected {
+		select {
+		case got := <-heartbeats:
+			if got != node {
+				t.Fatalf("heartbeat attributed to node %d, want %d", got, node)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no heartbeat of node %d", node)
+		}
+	}
+	select {
+	case got := <-heartbeats:
+		t.Fatalf("unexpected heartbeat of node %d", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// TestHeartbeatsOfIdentifiedPeer attributes heartbeats to the node of the handshake and answers them in order.
+func TestHeartbeatsOfIdentifiedPeer(t *testing.T) {
+	transport := testTransports(t)[0]
+	heartbeats := startHeartbeatNetworkLayer(t, transport.transport)
+	node := connect(t, transport)
+	node.handshake(3)
+
+	node.mustSend(voteMessage(t, 1))
+	node.mustSend(&protocol.Message{MessageType: protocol.MessageType_HEARTBEAT})
+	node.mustSend(voteMessage(t, 3))
+	terms := make([]uint64, 0, 3)
+	for i := 0; i < 3; i++ {
+		terms = append(terms, responseTerm(t, node.mustReceive()))
+	}
+	expectTerms(t, terms, 1, 0, 3)
+	expectHeartbeats(t, heartbeats, 3)
+}
+
+// TestHeartbeatsOfAnonymousPeer attributes heartbeats to the node the messages of the connection report, the node
+// faults are injected into, and ignores them before the first report.
+func TestHeartbeatsOfAnonymousPeer(t *testing.T) {
+	transport := testTransports(t)[0]
+	heartbeats := startHeartbeatNetworkLayer(t, transport.transport)
+	node := connect(t, transport)
+
+	node.mustSend(&protocol.Message{MessageType: protocol.MessageType_HEARTBEAT})
+	expectTerms(t, []uint64{responseTerm(t, node.mustReceive())}, 0)
+	expectHeartbeats(t, heartbeats)
+
+	// The vote was received by node 2, see voteMessage.
+	node.mustSend(voteMessage(t, 1))
+	expectTerms(t, []uint64{responseTerm(t, node.mustReceive())}, 1)
+	node.mustSend(&protocol.Message{MessageType: protocol.MessageType_HEARTBEAT})
+	expectTerms(t, []uint64{responseTerm(t, node.mustReceive())}, 0)
+	expectHeartbeats(t, heartbeats, 2)
+}
@@ -0,0 +1,95 @@
+package network
+
+import (
+	"github.com/FatProteins/master-thesis-code/metrics"
+	"time"
+)
+
+type ConnState int
+
+const (
+	// StateReconnecting means the socket could not be listened on and the DA retries with backoff.
+	StateReconnecting ConnState = iota
+	// StateListening means the DA listens on the socket, but no node is connected.
+	StateListening
+	// StateConnected means at least one node is connected.
+	StateConnected
+	// StateDraining means a connection is closed, but messages received on it are still being handled.
+	// Their responses are dropped.
+	StateDraining
+)
+
+var connStateNames = []string{"reconnecting", "listening", "connected", "draining"}
+
+func (state ConnState) String() string {
+	return connStateNames[state]
+}
+
+func (state ConnState) MarshalText() ([]byte, error) {
+	return []byte(state.String()), nil
+}
+
+// ConnectionInfo describes a single node connection.
+type ConnectionInfo struct {
+	Id       uint64    `json:"id"`
+	Peer     Peer      `json:"peer"`
+	State    ConnState `json:"state"`
+	Since    time.Time `json:"since"`
+	InFlight int64     `json:"inFlight"`
+}
+
+// Status describes the state of the network layer and all of its connections.
+type Status struct {
+	State       ConnState        `json:"state"`
+	Since       time.Time        `json:"since"`
+	Connections []ConnectionInfo `json:"connections"`
+}
+
+func (networkLayer *NetworkLayer) Status() Status {
+	networkLayer.connsMutex.Lock()
+	defer networkLayer.connsMutex.Unlock()
+
+	status := Status{State: networkLayer.state, Since: networkLayer.stateSince, Connections: make([]ConnectionInfo, 0, len(networkLayer.conns))}
+	for conn := range networkLayer.conns {
+		status.Connections = append(status.Connections, conn.info())
+	}
+	return status
+}
+
+// updateState derives the state of the network layer from the listener and connections.
+// Must be called with connsMutex held.
+func (networkLayer *NetworkLayer) updateState() {
+	state := StateReconnecting
+	if networkLayer.listener != nil {
+		state = StateListening
+		for conn := range networkLayer.conns {
+			if conn.state == StateConnected {
+				state = StateConnected
+				break
+			}
+			state = StateDraining
+		}
+	}
+
+	connected := 0
+	for conn := range networkLayer.conns {
+		if conn.state == StateConnected {
+			connected++
+		}
+	}
+	metrics.Connections.Set(float64(connected))
+
+	if state == networkLayer.state && !networkLayer.stateSince.IsZero() {
+		return
+	}
+	logger.Info("Network layer is %s", state.String())
+	networkLayer.state = state
+	networkLayer.stateSince = time.Now()
+	for idx, name := range connStateNames {
+		value := 0.0
+		if ConnState(idx) == state {
+			value = 1
+		}
+		metrics.ConnectionState.WithLabelValues(name).Set(value)
+	}
+}
@@ -0,0 +1,45 @@
+package network
+
+import (
+	"net"
+)
+
+type tcpTransport struct {
+	address string
+}
+
+// NewTcpTransport listens on a TCP address, so nodes can connect to a DA on another host.
+// Messages are framed like on a unix stream socket.
+func NewTcpTransport(address string) Transport {
+	return &tcpTransport{address: address}
+}
+
+func (transport *tcpTransport) Address() string {
+	return "tcp://" + transport.address
+}
+
+func (transport *tcpTransport) Listen() (Listener, error) {
+	listener, err := net.Listen("tcp", transport.address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tcpListener{Listener: listener}, nil
+}
+
+type tcpListener struct {
+	net.Listener
+}
+
+func (listener *tcpListener) Accept() (Conn, error) {
+	connection, err := listener.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if tcpConn, ok := connection.(*net.TCPConn); ok {
+		// Messages are small and every message waits for its response.
+		_ = tcpConn.SetNoDelay(true)
+	}
+	return newStreamConn(connection), nil
+}
@@ -0,0 +1,33 @@
+package network
+
+const (
+	TransportUnix = "unix"
+	TransportTcp  = "tcp"
+	TransportGrpc = "grpc"
+)
+
+// Transport is the way instrumented nodes connect to the DA.
+type Transport interface {
+	// Listen starts accepting connections. After the listener failed, Listen is called again.
+	Listen() (Listener, error)
+	// Address describes where the transport listens.
+	Address() string
+}
+
+// Listener accepts the connections of a transport.
+type Listener interface {
+	Accept() (Conn, error)
+	Close() error
+}
+
+// Conn is the connection to a single node. ReadMessage is only called by a single goroutine,
+// WriteMessage is never called concurrently.
+type Conn interface {
+	// ReadMessage reads the next whole protobuf message into the given buffer. Messages larger than the buffer are
+	// dropped with a MessageTooLargeError, the connection stays usable.
+	ReadMessage(buffer []byte) ([]byte, error)
+	// WriteMessage sends a marshalled protobuf message. The message is reused after WriteMessage returned, so it
+	// must be copied if it is sent asynchronously.
+	WriteMessage(message []byte) error
+	Close() error
+}
@@ -0,0 +1,73 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+)
+
+type unixTransport struct {
+	socketType string
+	path       string
+}
+
+// NewUnixTransport listens on a unix socket, which requires the DA and the node to share a volume.
+func NewUnixTransport(socketType string, path string) (Transport, error) {
+	if socketType != SocketTypeStream && socketType != SocketTypePacket {
+		return nil, errors.New("unsupported socket type '" + socketType + "'")
+	}
+
+	return &unixTransport{socketType: socketType, path: path}, nil
+}
+
+func (transport *unixTransport) Address() string {
+	return transport.socketType + "://" + transport.path
+}
+
+// Listen removes a stale socket file left by a previous run or listener and listens on the socket.
+func (transport *unixTransport) Listen() (Listener, error) {
+	info, err := os.Lstat(transport.path)
+	if err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("'%s' exists and is not a socket", transport.path)
+		}
+		logger.Info("Removing stale socket '%s'", transport.path)
+		err = os.Remove(transport.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.ListenUnix(transport.socketType, &net.UnixAddr{Name: transport.path, Net: transport.socketType})
+	if err != nil {
+		return nil, err
+	}
+
+	return &unixListener{UnixListener: listener, socketType: transport.socketType}, nil
+}
+
+type unixListener struct {
+	*net.UnixListener
+	socketType string
+}
+
+func (listener *unixListener) Accept() (Conn, error) {
+	connection, err := listener.AcceptUnix()
+	if err != nil {
+		return nil, err
+	}
+
+	if listener.socketType == SocketTypePacket {
+		conn, err := newPacketConn(connection)
+		if err != nil {
+			_ = connection.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+	return newStreamConn(connection), nil
+}
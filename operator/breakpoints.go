@@ -0,0 +1,187 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/FatProteins/master-thesis-code/plugin"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrUnknownBreakpoint = errors.New("no breakpoint with this id")
+var ErrNotPaused = errors.New("no paused message with this id")
+
+// Breakpoint pauses the node when a matching message arrives. All set conditions must hold:
+// the message type, the reporting node, the values of the given fields and, if NewValueOf is set,
+// a value of that field that differs from the last value seen from the node (e.g. a new term).
+type Breakpoint struct {
+	Id          uint64           `json:"id"`
+	MessageType string           `json:"messageType,omitempty"`
+	Node        *uint32          `json:"node,omitempty"`
+	Fields      map[string]int64 `json:"fields,omitempty"`
+	NewValueOf  string           `json:"newValueOf,omitempty"`
+	Hits        uint64           `json:"hits"`
+	lastValues  map[uint32]int64
+}
+
+// PausedMessage is a message whose response is held because it hit a breakpoint.
+type PausedMessage struct {
+	Id           uint64          `json:"id"`
+	BreakpointId uint64          `json:"breakpointId"`
+	Node         uint32          `json:"node"`
+	MessageType  string          `json:"messageType"`
+	Payload      json.RawMessage `json:"payload,omitempty"`
+	Since        time.Time       `json:"since"`
+	resume       chan struct{}
+}
+
+type Breakpoints struct {
+	mutex        sync.Mutex
+	nextId       uint64
+	nextPausedId uint64
+	breakpoints  map[uint64]*Breakpoint
+	paused       map[uint64]*PausedMessage
+}
+
+func NewBreakpoints() *Breakpoints {
+	return &Breakpoints{breakpoints: make(map[uint64]*Breakpoint), paused: make(map[uint64]*PausedMessage)}
+}
+
+func (breakpoints *Breakpoints) Add(breakpoint Breakpoint) Breakpoint {
+	breakpoints.mutex.Lock()
+	defer breakpoints.mutex.Unlock()
+
+	breakpoints.nextId++
+	breakpoint.Id = breakpoints.nextId
+	breakpoint.Hits = 0
+	breakpoint.lastValues = make(map[uint32]int64)
+	breakpoints.breakpoints[breakpoint.Id] = &breakpoint
+	logger.Info("Added breakpoint %d", breakpoint.Id)
+	return breakpoint
+}
+
+func (breakpoints *Breakpoints) Remove(id uint64) error {
+	breakpoints.mutex.Lock()
+	defer breakpoints.mutex.Unlock()
+
+	if _, ok := breakpoints.breakpoints[id]; !ok {
+		return ErrUnknownBreakpoint
+	}
+	delete(breakpoints.breakpoints, id)
+	return nil
+}
+
+func (breakpoints *Breakpoints) List() []Breakpoint {
+	breakpoints.mutex.Lock()
+	defer breakpoints.mutex.Unlock()
+
+	result := make([]Breakpoint, 0, len(breakpoints.breakpoints))
+	for _, breakpoint := range breakpoints.breakpoints {
+		result = append(result, *breakpoint)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+func (breakpoints *Breakpoints) Paused() []PausedMessage {
+	breakpoints.mutex.Lock()
+	defer breakpoints.mutex.Unlock()
+
+	result := make([]PausedMessage, 0, len(breakpoints.paused))
+	for _, paused := range breakpoints.paused {
+		result = append(result, *paused)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+func (breakpoints *Breakpoints) Resume(id uint64) error {
+	breakpoints.mutex.Lock()
+	defer breakpoints.mutex.Unlock()
+
+	paused, ok := breakpoints.paused[id]
+	if !ok {
+		return ErrNotPaused
+	}
+	delete(breakpoints.paused, id)
+	close(paused.resume)
+	return nil
+}
+
+// Check blocks until the message is resumed or the context is done if it matches a breakpoint. Without a match it
+// returns immediately.
+func (breakpoints *Breakpoints) Check(ctx context.Context, messageType string, node uint32, fields plugin.Fields) {
+	if breakpoints == nil {
+		return
+	}
+
+	breakpoints.mutex.Lock()
+	var hit *Breakpoint
+	for _, breakpoint := range breakpoints.breakpoints {
+		if breakpoint.matches(messageType, node, fields) && (hit == nil || breakpoint.Id < hit.Id) {
+			hit = breakpoint
+		}
+	}
+	if hit == nil {
+		breakpoints.mutex.Unlock()
+		return
+	}
+
+	hit.Hits++
+	breakpoints.nextPausedId++
+	paused := &PausedMessage{
+		Id:           breakpoints.nextPausedId,
+		BreakpointId: hit.Id,
+		Node:         node,
+		MessageType:  messageType,
+		Since:        time.Now(),
+		resume:       make(chan struct{}),
+	}
+	payload, err := fields.Json()
+	if err == nil {
+		paused.Payload = payload
+	}
+	breakpoints.paused[paused.Id] = paused
+	breakpoints.mutex.Unlock()
+
+	logger.Info("Breakpoint %d hit by '%s' message of node %d, holding response until resumed (paused message %d)",
+		hit.Id, messageType, node, paused.Id)
+	select {
+	case <-paused.resume:
+		logger.Info("Resumed paused message %d after %s", paused.Id, time.Since(paused.Since).String())
+	case <-ctx.Done():
+		breakpoints.mutex.Lock()
+		delete(breakpoints.paused, paused.Id)
+		breakpoints.mutex.Unlock()
+		logger.Info("Released paused message %d on shutdown", paused.Id)
+	}
+}
+
+// matches must be called with the lock held since it updates the last seen values.
+func (breakpoint *Breakpoint) matches(messageType string, node uint32, fields plugin.Fields) bool {
+	if len(breakpoint.MessageType) != 0 && breakpoint.MessageType != messageType {
+		return false
+	}
+	if breakpoint.Node != nil && *breakpoint.Node != node {
+		return false
+	}
+	for field, expected := range breakpoint.Fields {
+		value, err := fields.Field(field)
+		if err != nil || value != expected {
+			return false
+		}
+	}
+	if len(breakpoint.NewValueOf) == 0 {
+		return true
+	}
+
+	value, err := fields.Field(breakpoint.NewValueOf)
+	if err != nil {
+		return false
+	}
+	last, seen := breakpoint.lastValues[node]
+	breakpoint.lastValues[node] = value
+	return !seen || last != value
+}
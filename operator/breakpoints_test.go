@@ -0,0 +1,81 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/plugin"
+)
+
+var voteFields = plugin.ProtoFields(&protocol.VoteReceived{VotingNodeId: 2, VotedNodeId: 1, VoteGranted: true})
+
+// waitPaused waits until the given number of messages is paused.
+func waitPaused(t *testing.T, breakpoints *Breakpoints, count int) []PausedMessage {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		paused := breakpoints.Paused()
+		if len(paused) == count {
+			return paused
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d messages paused, want %d", len(paused), count)
+		}
+	}
+}
+
+func TestCheckBlocksUntilResumed(t *testing.T) {
+	breakpoints := NewBreakpoints()
+	node := uint32(1)
+	breakpoints.Add(Breakpoint{MessageType: "VOTE_RECEIVED", Node: &node})
+
+	// Messages of other nodes or types pass.
+	breakpoints.Check(context.Background(), "VOTE_RECEIVED", 2, voteFields)
+	breakpoints.Check(context.Background(), "HEARTBEAT", 1, voteFields)
+
+	returned := make(chan struct{})
+	go func() {
+		breakpoints.Check(context.Background(), "VOTE_RECEIVED", 1, voteFields)
+		close(returned)
+	}()
+	paused := waitPaused(t, breakpoints, 1)
+	select {
+	case <-returned:
+		t.Fatal("check returned before the message was resumed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	err := breakpoints.Resume(paused[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("check did not return after resume")
+	}
+}
+
+func TestCheckReturnsWhenContextIsDone(t *testing.T) {
+	breakpoints := NewBreakpoints()
+	breakpoints.Add(Breakpoint{MessageType: "VOTE_RECEIVED"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	returned := make(chan struct{})
+	go func() {
+		breakpoints.Check(ctx, "VOTE_RECEIVED", 1, voteFields)
+		close(returned)
+	}()
+	waitPaused(t, breakpoints, 1)
+
+	cancel()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("check did not return after the context was cancelled")
+	}
+	if paused := breakpoints.Paused(); len(paused) != 0 {
+		t.Errorf("%d messages still paused", len(paused))
+	}
+}
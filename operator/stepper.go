@@ -0,0 +1,134 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/setup"
+	"google.golang.org/protobuf/encoding/protojson"
+	"sort"
+	"sync"
+	"time"
+)
+
+var logger = daLogger.NewLogger("operator")
+
+var ErrUnknownMessage = errors.New("no pending message with this id")
+
+// PendingMessage is a message held by the Stepper until an operator chooses its action.
+type PendingMessage struct {
+	Id              uint64          `json:"id"`
+	Received        time.Time       `json:"received"`
+	Deadline        time.Time       `json:"deadline"`
+	MessageType     string          `json:"messageType"`
+	RequestedAction string          `json:"requestedAction"`
+	Payload         json.RawMessage `json:"payload,omitempty"`
+	decisionChan    chan setup.Decision
+}
+
+// Stepper holds every incoming message until an operator picks the action and its parameters.
+// If nobody decides within the timeout, the message is answered with Noop. Once the context is done, e.g. on
+// shutdown, the ActionPicker decides instead of the operator.
+type Stepper struct {
+	*setup.ActionPicker
+	ctx     context.Context
+	timeout time.Duration
+	mutex   sync.Mutex
+	nextId  uint64
+	pending map[uint64]*PendingMessage
+}
+
+func NewStepper(ctx context.Context, actionPicker *setup.ActionPicker, timeout time.Duration) *Stepper {
+	return &Stepper{ActionPicker: actionPicker, ctx: ctx, timeout: timeout, pending: make(map[uint64]*PendingMessage)}
+}
+
+func (stepper *Stepper) Decide(message *protocol.Message) setup.Decision {
+	if stepper.ctx.Err() != nil {
+		return stepper.ActionPicker.Decide(message)
+	}
+
+	now := time.Now()
+	pending := &PendingMessage{
+		Received:        now,
+		Deadline:        now.Add(stepper.timeout),
+		MessageType:     message.MessageType.String(),
+		RequestedAction: message.ActionType.String(),
+		decisionChan:    make(chan setup.Decision, 1),
+	}
+	if message.MessageObject != nil {
+		payload, err := protojson.Marshal(message.MessageObject)
+		if err == nil {
+			pending.Payload = payload
+		}
+	}
+
+	stepper.mutex.Lock()
+	stepper.nextId++
+	pending.Id = stepper.nextId
+	stepper.pending[pending.Id] = pending
+	stepper.mutex.Unlock()
+	logger.Info("Holding '%s' message %d until an action is chosen", pending.MessageType, pending.Id)
+
+	timer := time.NewTimer(stepper.timeout)
+	defer timer.Stop()
+	cancelled := false
+	select {
+	case decision := <-pending.decisionChan:
+		return decision
+	case <-timer.C:
+	case <-stepper.ctx.Done():
+		cancelled = true
+	}
+
+	stepper.mutex.Lock()
+	delete(stepper.pending, pending.Id)
+	stepper.mutex.Unlock()
+
+	select {
+	case decision := <-pending.decisionChan:
+		return decision
+	default:
+	}
+	if cancelled {
+		logger.Info("Released message %d on shutdown, deciding without operator", pending.Id)
+		return stepper.ActionPicker.Decide(message)
+	}
+	logger.Info("No action chosen for message %d within %s, falling back to Noop", pending.Id, stepper.timeout.String())
+	return setup.Decision{ActionType: protocol.ActionType_NOOP_ACTION_TYPE}
+}
+
+// Pending returns all held messages ordered by arrival.
+func (stepper *Stepper) Pending() []PendingMessage {
+	stepper.mutex.Lock()
+	defer stepper.mutex.Unlock()
+
+	result := make([]PendingMessage, 0, len(stepper.pending))
+	for _, pending := range stepper.pending {
+		result = append(result, *pending)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result
+}
+
+// Choose releases the pending message with the given id using the chosen action.
+// A negative duration selects the configured duration of the action.
+func (stepper *Stepper) Choose(id uint64, actionType protocol.ActionType, duration time.Duration) error {
+	if duration < 0 {
+		duration = stepper.ActionPicker.Duration(actionType)
+	}
+
+	stepper.mutex.Lock()
+	defer stepper.mutex.Unlock()
+	pending, ok := stepper.pending[id]
+	if !ok {
+		return ErrUnknownMessage
+	}
+
+	// Sending while holding the lock guarantees that Decide sees the decision even if it times out concurrently.
+	delete(stepper.pending, id)
+	pending.decisionChan <- setup.Decision{ActionType: actionType, Duration: duration}
+	logger.Info("Operator chose '%s' for %s for message %d", actionType.String(), duration.String(), id)
+	return nil
+}
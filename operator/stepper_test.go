@@ -0,0 +1,89 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/setup"
+)
+
+// TestStepperHoldsMessagesOfSeveralNodes decides concurrently, like the workers of different connections do. All
+// messages are pending until an action is chosen for each of them.
+func TestStepperHoldsMessagesOfSeveralNodes(t *testing.T) {
+	stepper := NewStepper(context.Background(), setup.NewActionPicker(setup.FaultConfig{}), 5*time.Second)
+	decisions := make(chan setup.Decision, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			decisions <- stepper.Decide(&protocol.Message{MessageType: protocol.MessageType_VOTE_RECEIVED})
+		}()
+	}
+
+	var pending []PendingMessage
+	for deadline := time.Now().Add(5 * time.Second); len(pending) < 2; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d messages pending, want 2", len(pending))
+		}
+		pending = stepper.Pending()
+	}
+
+	for _, message := range pending {
+		err := stepper.Choose(message.Id, protocol.ActionType_HALT_ACTION_TYPE, time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		decision := <-decisions
+		if decision.ActionType != protocol.ActionType_HALT_ACTION_TYPE || decision.Duration != time.Second {
+			t.Errorf("got %+v, want the chosen halt", decision)
+		}
+	}
+	if remaining := stepper.Pending(); len(remaining) != 0 {
+		t.Errorf("%d messages still pending", len(remaining))
+	}
+}
+
+func TestStepperFallsBackToNoop(t *testing.T) {
+	stepper := NewStepper(context.Background(), setup.NewActionPicker(setup.FaultConfig{}), 10*time.Millisecond)
+	decision := stepper.Decide(&protocol.Message{MessageType: protocol.MessageType_VOTE_RECEIVED, ActionType: protocol.ActionType_STOP_ACTION_TYPE})
+	if decision.ActionType != protocol.ActionType_NOOP_ACTION_TYPE {
+		t.Errorf("got '%s', want noop after the timeout", decision.ActionType.String())
+	}
+}
+
+func TestStepperDecidesWithoutOperatorOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	config := setup.FaultConfig{}
+	config.Actions.Halt.MaxDuration = 100
+	stepper := NewStepper(ctx, setup.NewActionPicker(config), time.Minute)
+	message := &protocol.Message{MessageType: protocol.MessageType_VOTE_RECEIVED, ActionType: protocol.ActionType_HALT_ACTION_TYPE}
+	decisions := make(chan setup.Decision, 1)
+	go func() {
+		decisions <- stepper.Decide(message)
+	}()
+	for deadline := time.Now().Add(5 * time.Second); len(stepper.Pending()) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("message not pending")
+		}
+	}
+
+	cancel()
+	select {
+	case decision := <-decisions:
+		if decision.ActionType != protocol.ActionType_HALT_ACTION_TYPE || decision.Duration != 100*time.Millisecond {
+			t.Errorf("got %+v, want the halt of the ActionPicker", decision)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Decide still blocks after the context is done")
+	}
+	if pending := stepper.Pending(); len(pending) != 0 {
+		t.Errorf("%d messages still pending", len(pending))
+	}
+
+	// Later messages are not held at all.
+	if decision := stepper.Decide(message); decision.ActionType != protocol.ActionType_HALT_ACTION_TYPE {
+		t.Errorf("got %+v after the context is done, want the halt of the ActionPicker", decision)
+	}
+}
@@ -0,0 +1,186 @@
+// Package plugin lets consensus protocols that are not modelled by the MessageType enum send their events as
+// CustomData. A plugin decodes the payload of its protocol and gives policies, e.g. breakpoints, access to it.
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+	"sort"
+	"sync"
+)
+
+var ErrUnknownProtocol = errors.New("no plugin registered for protocol")
+
+// Plugin decodes the CustomData of one protocol.
+type Plugin interface {
+	// Name is the protocolName of the CustomData handled by the plugin.
+	Name() string
+	// Decode decodes the payload into a typed message.
+	Decode(data *anypb.Any) (proto.Message, error)
+	// EventType names the event in metrics, events, the fault log and breakpoints.
+	EventType(decoded proto.Message) string
+	// Node returns the node that reported the event.
+	Node(decoded proto.Message) uint32
+	// Field returns the value of a field of the event as used by policies.
+	Field(decoded proto.Message, name string) (int64, error)
+	// RenderJson renders the event for humans.
+	RenderJson(decoded proto.Message) ([]byte, error)
+}
+
+// Fields gives policies access to the fields of a decoded event, regardless of whether it was decoded by a plugin.
+type Fields interface {
+	Field(name string) (int64, error)
+	Json() ([]byte, error)
+}
+
+type Registry struct {
+	mutex   sync.RWMutex
+	plugins map[string]Plugin
+}
+
+func NewRegistry() *Registry {
+	return &Registry{plugins: make(map[string]Plugin)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry plugins register themselves with on init.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a plugin to the default registry.
+func Register(plugin Plugin) error {
+	return defaultRegistry.Register(plugin)
+}
+
+func (registry *Registry) Register(plugin Plugin) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if _, ok := registry.plugins[plugin.Name()]; ok {
+		return fmt.Errorf("plugin for protocol '%s' is already registered", plugin.Name())
+	}
+	registry.plugins[plugin.Name()] = plugin
+	return nil
+}
+
+func (registry *Registry) Lookup(name string) (Plugin, bool) {
+	if registry == nil {
+		return nil, false
+	}
+
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	plugin, ok := registry.plugins[name]
+	return plugin, ok
+}
+
+// Names returns the protocols with a registered plugin.
+func (registry *Registry) Names() []string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	names := make([]string, 0, len(registry.plugins))
+	for name := range registry.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Decode decodes custom data with the plugin registered for its protocol.
+func (registry *Registry) Decode(customData *protocol.CustomData) (*Decoded, error) {
+	plugin, ok := registry.Lookup(customData.ProtocolName)
+	if !ok {
+		return nil, fmt.Errorf("%w '%s'", ErrUnknownProtocol, customData.ProtocolName)
+	}
+
+	message, err := plugin.Decode(customData.Data)
+	if err != nil {
+		return nil, err
+	}
+	return &Decoded{Plugin: plugin, Message: message}, nil
+}
+
+// Decoded is an event decoded by a plugin.
+type Decoded struct {
+	Plugin  Plugin
+	Message proto.Message
+}
+
+func (decoded *Decoded) EventType() string {
+	return decoded.Plugin.EventType(decoded.Message)
+}
+
+func (decoded *Decoded) Node() uint32 {
+	return decoded.Plugin.Node(decoded.Message)
+}
+
+func (decoded *Decoded) Field(name string) (int64, error) {
+	return decoded.Plugin.Field(decoded.Message, name)
+}
+
+func (decoded *Decoded) Json() ([]byte, error) {
+	return decoded.Plugin.RenderJson(decoded.Message)
+}
+
+type protoFields struct {
+	message proto.Message
+}
+
+// ProtoFields gives access to the fields of a protobuf message, e.g. of the core events.
+func ProtoFields(message proto.Message) Fields {
+	return protoFields{message: message}
+}
+
+func (fields protoFields) Field(name string) (int64, error) {
+	return FieldValue(fields.message, name)
+}
+
+func (fields protoFields) Json() ([]byte, error) {
+	if fields.message == nil {
+		return nil, errors.New("no message to render")
+	}
+	return protojson.Marshal(fields.message)
+}
+
+// FieldValue returns the value of a scalar integer or bool field, addressed by its proto or JSON name.
+func FieldValue(message proto.Message, name string) (int64, error) {
+	if message == nil {
+		return 0, fmt.Errorf("no message to read field '%s' from", name)
+	}
+
+	reflected := message.ProtoReflect()
+	fields := reflected.Descriptor().Fields()
+	field := fields.ByJSONName(name)
+	if field == nil {
+		field = fields.ByName(protoreflect.Name(name))
+	}
+	if field == nil {
+		return 0, fmt.Errorf("message '%s' has no field '%s'", reflected.Descriptor().Name(), name)
+	}
+
+	value := reflected.Get(field)
+	switch field.Kind() {
+	case protoreflect.BoolKind:
+		if value.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind,
+		protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+		return value.Int(), nil
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return int64(value.Uint()), nil
+	case protoreflect.EnumKind:
+		return int64(value.Enum()), nil
+	}
+
+	return 0, fmt.Errorf("field '%s' is not an integer field", name)
+}
@@ -0,0 +1,150 @@
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// newTestPlugin is a protocol whose events are core messages, reported by the receiving node.
+func newTestPlugin() *ProtoPlugin {
+	return NewProtoPlugin("test", "receivingNodeId", &protocol.VoteRequestReceived{}, &protocol.LogEntryReplicated{})
+}
+
+func customData(t *testing.T, protocolName string, event proto.Message) *protocol.CustomData {
+	t.Helper()
+	data, err := anypb.New(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &protocol.CustomData{ProtocolName: protocolName, Data: data}
+}
+
+func TestRegister(t *testing.T) {
+	registry := NewRegistry()
+	err := registry.Register(newTestPlugin())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = registry.Register(newTestPlugin()); err == nil {
+		t.Error("registered a second plugin for the same protocol")
+	}
+
+	registered, ok := registry.Lookup("test")
+	if !ok || registered.Name() != "test" {
+		t.Errorf("got %v, %t, want the test plugin", registered, ok)
+	}
+	if _, ok = registry.Lookup("unknown"); ok {
+		t.Error("found a plugin for an unregistered protocol")
+	}
+	if names := registry.Names(); len(names) != 1 || names[0] != "test" {
+		t.Errorf("got names %v, want [test]", names)
+	}
+
+	var nilRegistry *Registry
+	if _, ok = nilRegistry.Lookup("test"); ok {
+		t.Error("found a plugin in a nil registry")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	registry := NewRegistry()
+	_ = registry.Register(newTestPlugin())
+
+	decoded, err := registry.Decode(customData(t, "test", &protocol.VoteRequestReceived{RequestingNodeId: 1, ReceivingNodeId: 2, Term: 7}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if eventType := decoded.EventType(); eventType != "test.VoteRequestReceived" {
+		t.Errorf("got event type '%s', want 'test.VoteRequestReceived'", eventType)
+	}
+	if node := decoded.Node(); node != 2 {
+		t.Errorf("got node %d, want the receiving node 2", node)
+	}
+	if term, err := decoded.Field("term"); err != nil || term != 7 {
+		t.Errorf("got term %d, %v, want 7", term, err)
+	}
+	rendered, err := decoded.Json()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err = json.Unmarshal(rendered, &fields); err != nil || fields["term"] != "7" {
+		t.Errorf("got %s, %v, want the term rendered", rendered, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	registry := NewRegistry()
+	_ = registry.Register(newTestPlugin())
+
+	for _, test := range []struct {
+		name       string
+		customData *protocol.CustomData
+	}{
+		{"unknown protocol", customData(t, "unknown", &protocol.VoteRequestReceived{})},
+		{"unknown event", customData(t, "test", &protocol.VoteReceived{})},
+		{"no payload", &protocol.CustomData{ProtocolName: "test"}},
+		{"malformed payload", &protocol.CustomData{ProtocolName: "test", Data: &anypb.Any{
+			TypeUrl: "type.googleapis.com/" + string((&protocol.VoteRequestReceived{}).ProtoReflect().Descriptor().FullName()),
+			Value:   []byte{0xff},
+		}}},
+	} {
+		_, err := registry.Decode(test.customData)
+		if err == nil {
+			t.Errorf("%s: decoded without error", test.name)
+		}
+		if unknown := errors.Is(err, ErrUnknownProtocol); unknown != (test.name == "unknown protocol") {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+	}
+}
+
+func TestFieldValue(t *testing.T) {
+	vote := &protocol.VoteReceived{VotingNodeId: 3, VotedNodeId: 4, VoteGranted: true}
+	replicated := &protocol.LogEntryReplicated{LeaderId: 1, ReceivingNodeId: 2, LogEntryNumber: -5}
+	message := &protocol.Message{MessageType: protocol.MessageType_VOTE_RECEIVED}
+	descriptor := &descriptorpb.FieldDescriptorProto{OneofIndex: proto.Int32(3)}
+
+	for _, test := range []struct {
+		message  proto.Message
+		field    string
+		expected int64
+		valid    bool
+	}{
+		{vote, "votingNodeId", 3, true},
+		// Fields are addressed by their JSON or their proto name.
+		{descriptor, "oneofIndex", 3, true},
+		{descriptor, "oneof_index", 3, true},
+		{vote, "voteGranted", 1, true},
+		{&protocol.VoteReceived{}, "voteGranted", 0, true},
+		{replicated, "logEntryNumber", -5, true},
+		{message, "messageType", int64(protocol.MessageType_VOTE_RECEIVED), true},
+		{message, "messageObject", 0, false},
+		{vote, "term", 0, false},
+		{nil, "term", 0, false},
+	} {
+		value, err := FieldValue(test.message, test.field)
+		if (err == nil) != test.valid || value != test.expected {
+			t.Errorf("field '%s' of %v: got %d, %v, want %d and valid %t", test.field, test.message, value, err, test.expected, test.valid)
+		}
+	}
+}
+
+func TestProtoFields(t *testing.T) {
+	fields := ProtoFields(&protocol.VoteReceived{VotingNodeId: 3})
+	if value, err := fields.Field("votingNodeId"); err != nil || value != 3 {
+		t.Errorf("got %d, %v, want 3", value, err)
+	}
+	if _, err := fields.Json(); err != nil {
+		t.Error(err)
+	}
+	if _, err := ProtoFields(nil).Json(); err == nil {
+		t.Error("rendered fields without message")
+	}
+}
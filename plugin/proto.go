@@ -0,0 +1,70 @@
+package plugin
+
+import (
+	"fmt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ProtoPlugin is a plugin for protocols whose events are protobuf messages. Events are named after their message,
+// fields are read by reflection and the reporting node is read from a field present in all events.
+type ProtoPlugin struct {
+	name      string
+	nodeField string
+	types     map[protoreflect.FullName]protoreflect.MessageType
+}
+
+// NewProtoPlugin creates a plugin decoding the given event messages.
+func NewProtoPlugin(name string, nodeField string, events ...proto.Message) *ProtoPlugin {
+	types := make(map[protoreflect.FullName]protoreflect.MessageType, len(events))
+	for _, event := range events {
+		messageType := event.ProtoReflect().Type()
+		types[messageType.Descriptor().FullName()] = messageType
+	}
+
+	return &ProtoPlugin{name: name, nodeField: nodeField, types: types}
+}
+
+func (plugin *ProtoPlugin) Name() string {
+	return plugin.name
+}
+
+func (plugin *ProtoPlugin) Decode(data *anypb.Any) (proto.Message, error) {
+	if data == nil {
+		return nil, fmt.Errorf("custom data of protocol '%s' has no payload", plugin.name)
+	}
+
+	messageType, ok := plugin.types[data.MessageName()]
+	if !ok {
+		return nil, fmt.Errorf("protocol '%s' has no event '%s'", plugin.name, data.MessageName())
+	}
+
+	message := messageType.New().Interface()
+	err := anypb.UnmarshalTo(data, message, proto.UnmarshalOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (plugin *ProtoPlugin) EventType(decoded proto.Message) string {
+	return plugin.name + "." + string(decoded.ProtoReflect().Descriptor().Name())
+}
+
+func (plugin *ProtoPlugin) Node(decoded proto.Message) uint32 {
+	node, err := FieldValue(decoded, plugin.nodeField)
+	if err != nil {
+		return 0
+	}
+	return uint32(node)
+}
+
+func (plugin *ProtoPlugin) Field(decoded proto.Message, name string) (int64, error) {
+	return FieldValue(decoded, name)
+}
+
+func (plugin *ProtoPlugin) RenderJson(decoded proto.Message) ([]byte, error) {
+	return protojson.Marshal(decoded)
+}
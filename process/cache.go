@@ -0,0 +1,111 @@
+package process
+
+import (
+	"github.com/FatProteins/master-thesis-code/metrics"
+	"github.com/FatProteins/master-thesis-code/network"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/plugin"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/proto"
+)
+
+// noFields are the fields of messages without payload.
+var noFields = plugin.ProtoFields(nil)
+
+type cachedPayload struct {
+	payload proto.Message
+	fields  plugin.Fields
+}
+
+// payloadCache reuses one payload per message type, so decoding the core events allocates nothing. A payload is
+// only valid until the next message of its type is decoded. It is only used by the goroutine of its worker.
+type payloadCache struct {
+	payloads map[protocol.MessageType]cachedPayload
+}
+
+func newPayloadCache() *payloadCache {
+	return &payloadCache{payloads: make(map[protocol.MessageType]cachedPayload)}
+}
+
+// decode returns the decoded payload and its fields, or nil and noFields for messages without payload.
+func (cache *payloadCache) decode(message *protocol.Message) (proto.Message, plugin.Fields, error) {
+	cached, ok := cache.payloads[message.MessageType]
+	if !ok {
+		if !network.HasPayload(message.MessageType) {
+			_, err := network.Decode(message)
+			return nil, noFields, err
+		}
+
+		payload, err := network.NewPayload(message.MessageType)
+		if err != nil {
+			return nil, noFields, err
+		}
+		cached = cachedPayload{payload: payload, fields: plugin.ProtoFields(payload)}
+		cache.payloads[message.MessageType] = cached
+	}
+
+	err := network.DecodeInto(message, cached.payload)
+	if err != nil {
+		return nil, noFields, err
+	}
+	return cached.payload, cached.fields, nil
+}
+
+type messageMetrics struct {
+	received       prometheus.Counter
+	overhead       prometheus.Observer
+	budgetExceeded prometheus.Counter
+	handling       map[string]prometheus.Observer
+}
+
+type actionMetrics struct {
+	performed prometheus.Counter
+	duration  prometheus.Observer
+}
+
+// metricsCache caches the metrics per label value, since WithLabelValues allocates on every call. It is only
+// used by the goroutine of its worker.
+type metricsCache struct {
+	messages map[string]*messageMetrics
+	actions  map[string]*actionMetrics
+}
+
+func newMetricsCache() *metricsCache {
+	return &metricsCache{messages: make(map[string]*messageMetrics), actions: make(map[string]*actionMetrics)}
+}
+
+func (cache *metricsCache) message(messageType string) *messageMetrics {
+	messageMetric, ok := cache.messages[messageType]
+	if !ok {
+		messageMetric = &messageMetrics{
+			received:       metrics.MessagesReceived.WithLabelValues(messageType),
+			overhead:       metrics.HandlingOverhead.WithLabelValues(messageType),
+			budgetExceeded: metrics.NoopBudgetExceeded.WithLabelValues(messageType),
+			handling:       make(map[string]prometheus.Observer),
+		}
+		cache.messages[messageType] = messageMetric
+	}
+	return messageMetric
+}
+
+func (cache *metricsCache) handling(messageType string, action string) prometheus.Observer {
+	messageMetric := cache.message(messageType)
+	handling, ok := messageMetric.handling[action]
+	if !ok {
+		handling = metrics.HandlingDuration.WithLabelValues(messageType, action)
+		messageMetric.handling[action] = handling
+	}
+	return handling
+}
+
+func (cache *metricsCache) action(action string) *actionMetrics {
+	actionMetric, ok := cache.actions[action]
+	if !ok {
+		actionMetric = &actionMetrics{
+			performed: metrics.ActionsPerformed.WithLabelValues(action),
+			duration:  metrics.ActionDuration.WithLabelValues(action),
+		}
+		cache.actions[action] = actionMetric
+	}
+	return actionMetric
+}
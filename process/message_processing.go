@@ -2,24 +2,82 @@ package process
 
 import (
 	"context"
+	"github.com/FatProteins/master-thesis-code/events"
+	"github.com/FatProteins/master-thesis-code/faultlog"
+	"github.com/FatProteins/master-thesis-code/liveness"
 	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/metrics"
 	"github.com/FatProteins/master-thesis-code/network"
 	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/operator"
+	"github.com/FatProteins/master-thesis-code/plugin"
 	"github.com/FatProteins/master-thesis-code/setup"
+	"github.com/FatProteins/master-thesis-code/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"google.golang.org/protobuf/proto"
-	"google.golang.org/protobuf/types/known/anypb"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var logger = daLogger.NewLogger("process")
 
+// Processor handles the messages of every connection in order, but the connections independently of each other:
+// an action performed for one node, e.g. a halt, does not hold the messages of the other nodes.
 type Processor struct {
 	messageChan  <-chan network.Message
 	respChan     chan<- network.Message
-	actionPicker *setup.ActionPicker
+	actionPicker setup.ActionDecider
+	faultLog     *faultlog.Writer
+	eventHub     *events.Hub
+	breakpoints  *operator.Breakpoints
+	plugins      *plugin.Registry
+	liveness     *liveness.Monitor
+
+	workersMutex sync.Mutex
+	workers      map[uint64]*worker
+
+	noopBudget        time.Duration
+	budgetMutex       sync.Mutex
+	budgetExceeded    uint64
+	lastBudgetWarning time.Time
+}
+
+// worker handles the messages of a single connection. Its caches are only used by its goroutine.
+type worker struct {
+	queue chan network.Message
+	// backlog counts the messages dispatched to the worker but not yet taken up, including those dispatch is
+	// blocked on. It is only incremented with the workers mutex held, so retire sees every dispatched message.
+	backlog  atomic.Int64
+	payloads *payloadCache
+	metrics  *metricsCache
+	// lastDone is when the worker last finished a message. Messages wait behind it, e.g. behind a halt, which is no
+	// overhead of theirs.
+	lastDone time.Time
+}
+
+const (
+	// budgetWarningInterval limits how often exceeding the noop budget is logged, logging every message would add
+	// to the overhead.
+	budgetWarningInterval = 10 * time.Second
+	// workerIdleTimeout is how long the worker of a connection waits for messages before it stops, e.g. because the
+	// connection was closed.
+	workerIdleTimeout = time.Minute
+	// workerQueueSize bounds the messages queued per connection. A node blocks on every response, so a connection
+	// only queues more messages if the node reports concurrently.
+	workerQueueSize = 1024
+)
+
+func NewProcessor(messageChan <-chan network.Message, respChan chan<- network.Message, actionPicker setup.ActionDecider, faultLog *faultlog.Writer, eventHub *events.Hub, breakpoints *operator.Breakpoints, plugins *plugin.Registry, liveness *liveness.Monitor) *Processor {
+	return &Processor{messageChan: messageChan, actionPicker: actionPicker, faultLog: faultLog, eventHub: eventHub, breakpoints: breakpoints, plugins: plugins, liveness: liveness, workers: make(map[uint64]*worker)}
 }
 
-func NewProcessor(messageChan <-chan network.Message, respChan chan<- network.Message, actionPicker *setup.ActionPicker) *Processor {
-	return &Processor{messageChan: messageChan, actionPicker: actionPicker}
+// SetNoopBudget sets the longest the DA may take to handle a message it performs noop for, from reading the
+// message, or from finishing the previous message of its connection if that was later, until writing its response.
+// Exceeding it is counted and logged, because the DA then distorts the
+// measurements of the instrumented nodes. Zero disables the budget. Must be called before RunAsync.
+func (processor *Processor) SetNoopBudget(budget time.Duration) {
+	processor.noopBudget = budget
 }
 
 func (processor *Processor) RunAsync(ctx context.Context) {
@@ -29,53 +87,319 @@ func (processor *Processor) RunAsync(ctx context.Context) {
 			case <-ctx.Done():
 				return
 			case message := <-processor.messageChan:
-				processor.handleMessage(message)
+				processor.dispatch(ctx, message)
 			}
 		}
 	}()
 }
 
-func (processor *Processor) handleMessage(message network.Message) {
+// Backlog returns the number of messages queued at the workers of all connections.
+func (processor *Processor) Backlog() int {
+	processor.workersMutex.Lock()
+	defer processor.workersMutex.Unlock()
+	backlog := int64(0)
+	for _, connWorker := range processor.workers {
+		backlog += connWorker.backlog.Load()
+	}
+	return int(backlog)
+}
+
+// dispatch queues the message at the worker of its connection, starting the worker if there is none. If the queue
+// of the connection is full, dispatch blocks, and with it the reading of further messages.
+func (processor *Processor) dispatch(ctx context.Context, message network.Message) {
+	connId := message.ConnId()
+	processor.workersMutex.Lock()
+	connWorker, ok := processor.workers[connId]
+	if !ok {
+		connWorker = &worker{queue: make(chan network.Message, workerQueueSize), payloads: newPayloadCache(), metrics: newMetricsCache()}
+		processor.workers[connId] = connWorker
+		go processor.work(ctx, connId, connWorker)
+	}
+	connWorker.backlog.Add(1)
+	processor.workersMutex.Unlock()
+
+	select {
+	case <-ctx.Done():
+	case connWorker.queue <- message:
+	}
+}
+
+// work handles the messages of a connection until it was idle for workerIdleTimeout.
+func (processor *Processor) work(ctx context.Context, connId uint64, connWorker *worker) {
+	idle := time.NewTimer(workerIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case message := <-connWorker.queue:
+			connWorker.backlog.Add(-1)
+			processor.handleMessage(ctx, connWorker, message)
+			connWorker.lastDone = time.Now()
+		case <-idle.C:
+			if processor.retire(connId, connWorker) {
+				return
+			}
+			idle.Reset(workerIdleTimeout)
+			continue
+		}
+
+		if !idle.Stop() {
+			select {
+			case <-idle.C:
+			default:
+			}
+		}
+		idle.Reset(workerIdleTimeout)
+	}
+}
+
+// retire removes the worker if no message was dispatched meanwhile. A later message of the connection starts a new
+// one.
+func (processor *Processor) retire(connId uint64, connWorker *worker) bool {
+	processor.workersMutex.Lock()
+	defer processor.workersMutex.Unlock()
+	if connWorker.backlog.Load() != 0 {
+		return false
+	}
+	delete(processor.workers, connId)
+	return true
+}
+
+// handleMessage decides and performs the action for a message and responds. For noop decisions of the core
+// events, it allocates nothing unless events are subscribed to, breakpoints are set, tracing is enabled or debug
+// logs are written.
+func (processor *Processor) handleMessage(ctx context.Context, connWorker *worker, message network.Message) {
 	defer message.FreeMessage()
+	ctx, span := tracing.StartAt(ctx, "handle message", message.Received())
+	defer span.End()
+	_, queuedSpan := tracing.StartAt(ctx, "queued", message.Received())
+	queuedSpan.End()
+	if message.Stale() {
+		logger.Debug("Dropping message of closed connection to %s", message.Peer().String())
+		metrics.DroppedResponses.Inc()
+		if span.IsRecording() {
+			span.SetAttributes(attribute.Bool("da.dropped", true))
+		}
+		return
+	}
 	logger.Debug("Handling message")
 
-	var err error
-	switch message.MessageType {
-	case protocol.MessageType_VOTE_REQUEST_RECEIVED:
-		m := protocol.VoteRequestReceived{}
-		err = anypb.UnmarshalTo(message.MessageObject, &m, proto.UnmarshalOptions{})
-	case protocol.MessageType_VOTE_RECEIVED:
-		m := protocol.VoteReceived{}
-		err = anypb.UnmarshalTo(message.MessageObject, &m, proto.UnmarshalOptions{})
-	case protocol.MessageType_LOG_ENTRY_REPLICATED:
-		m := protocol.LogEntryReplicated{}
-		err = anypb.UnmarshalTo(message.MessageObject, &m, proto.UnmarshalOptions{})
-	case protocol.MessageType_LOG_ENTRY_COMMITTED:
-		m := protocol.LogEntryCommitted{}
-		err = anypb.UnmarshalTo(message.MessageObject, &m, proto.UnmarshalOptions{})
-	case protocol.MessageType_LEADER_SUSPECTED:
-		m := protocol.LeaderSuspected{}
-		err = anypb.UnmarshalTo(message.MessageObject, &m, proto.UnmarshalOptions{})
-	case protocol.MessageType_FOLLOWER_SUSPECTED:
-		m := protocol.FollowerSuspected{}
-		err = anypb.UnmarshalTo(message.MessageObject, &m, proto.UnmarshalOptions{})
-	}
+	_, decodeSpan := tracing.Start(ctx, "decode")
+	decoded, fields, err := connWorker.payloads.decode(message.Message)
 	if err != nil {
+		logger.ErrorErr(err, "Failed to decode '%s' message", message.MessageType.String())
+		metrics.UndecodableMessages.WithLabelValues(message.MessageType.String()).Inc()
+		tracing.Fail(decodeSpan, err)
+	}
+
+	messageType := message.MessageType.String()
+	node := messageNode(message, decoded)
+	reported := decoded != nil
+	if message.CustomData != nil {
+		custom, err := processor.plugins.Decode(message.CustomData)
+		if err != nil {
+			logger.ErrorErr(err, "Failed to decode custom data of protocol '%s'", message.CustomData.ProtocolName)
+		} else {
+			messageType = custom.EventType()
+			fields = custom
+			reported = true
+			if !message.Peer().Identified {
+				node = custom.Node()
+			}
+		}
+	}
+	decodeSpan.End()
+	if reported && !message.Peer().Identified {
+		message.AttributeTo(node)
+	}
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Node(node), tracing.MessageType(messageType))
+	}
+	messageMetrics := connWorker.metrics.message(messageType)
+	messageMetrics.received.Inc()
 
+	publishEvents := processor.eventHub.HasSubscribers()
+	if publishEvents {
+		processor.publish(events.KindMessage, messageType, node, fields, nil, 0)
 	}
 
-	logger.Debug("Unread messages in queue: %d", len(processor.messageChan))
+	if logger.Enabled(daLogger.LevelDebug) {
+		logger.Debug("Unread messages in queue: %d", connWorker.backlog.Load())
+	}
 	//action := processor.actionPicker.DetermineAction()
-	action := processor.actionPicker.GetAction(message.ActionType)
-	logger.Info("Performing '%s' action", action.Name())
-	action.Perform(message.ResetConn)
-	logger.Info("Done with '%s' action", action.Name())
+	_, decideSpan := tracing.Start(ctx, "decide")
+	decision := processor.actionPicker.Decide(message.Message)
+	if !message.Peer().Supports(decision.ActionType) {
+		logger.With(daLogger.Node(node), daLogger.MessageType(messageType)).Info("%s does not support '%s', performing noop instead", message.Peer().String(), decision.ActionType.String())
+		metrics.UnsupportedActions.WithLabelValues(decision.ActionType.String()).Inc()
+		decision = setup.Decision{ActionType: protocol.ActionType_NOOP_ACTION_TYPE}
+	}
+	action := processor.actionPicker.GetAction(decision.ActionType)
+	decideSpan.End()
+	if span.IsRecording() {
+		span.SetAttributes(tracing.Action(action.Name()))
+	}
+
+	// Faults are logged as info, noop only as debug to keep the hot path free of allocations.
+	fault := decision.ActionType != protocol.ActionType_NOOP_ACTION_TYPE
+	logLevel := daLogger.LevelDebug
+	if fault {
+		logLevel = daLogger.LevelInfo
+	}
+	var actionLogger *daLogger.Logger
+	if logger.Enabled(logLevel) {
+		actionLogger = logger.With(daLogger.Node(node), daLogger.MessageType(messageType), daLogger.Action(action.Name()))
+		actionLogger.Log(logLevel, "Performing '%s' action", action.Name())
+	}
+	if fault {
+		processor.liveness.BeginDowntime(node, action.Name())
+	}
+	performCtx, performSpan := tracing.Start(ctx, "perform")
+	start := time.Now()
+	action.Perform(performCtx, node, decision.Duration, message.ResetConnFunc())
+	end := time.Now()
+	performSpan.End()
+	processor.liveness.EndDowntime(node)
+	if actionLogger != nil {
+		actionLogger.With(daLogger.Duration(end.Sub(start))).Log(logLevel, "Done with '%s' action", action.Name())
+	}
+	actionMetrics := connWorker.metrics.action(action.Name())
+	actionMetrics.performed.Inc()
+	actionMetrics.duration.Observe(end.Sub(start).Seconds())
+	if processor.faultLog != nil && fault {
+		processor.logFault(action, start, end, messageType, node, fields)
+	}
+	if publishEvents {
+		processor.publish(events.KindAction, messageType, node, fields, action, end.Sub(start))
+	}
+
+	_, breakpointSpan := tracing.Start(ctx, "breakpoint")
+	breakpointStart := time.Now()
+	processor.breakpoints.Check(ctx, messageType, node, fields)
+	held := end.Sub(start) + time.Since(breakpointStart)
+	breakpointSpan.End()
+
+	_, respondSpan := tracing.Start(ctx, "respond")
 	response := message.GetResponse()
 	err = action.GenerateResponse(response)
 	if err != nil {
-		logger.ErrorErr(err, "Failed to generate DA response. Sending default response instead.")
+		logger.With(daLogger.Node(node), daLogger.MessageType(messageType), daLogger.Action(action.Name())).ErrorErr(err, "Failed to generate DA response. Sending default response instead.")
+		metrics.ResponseMarshalErrors.Inc()
+		tracing.Fail(respondSpan, err)
 		response.MessageType = protocol.MessageType_DA_RESPONSE
 	}
-
+	response.ActionType = decision.ActionType
 	message.Respond()
+	respondSpan.End()
+	processor.measureOverhead(connWorker, message, messageType, messageMetrics, decision.ActionType, action.Name(), held)
+}
+
+// measureOverhead records the time from reading the message until writing its response. The overhead of the DA
+// excludes the time the message was held on purpose, i.e. performing the action and pausing at breakpoints.
+func (processor *Processor) measureOverhead(connWorker *worker, message network.Message, messageType string, messageMetrics *messageMetrics, actionType protocol.ActionType, actionName string, held time.Duration) {
+	now := time.Now()
+	handling := now.Sub(message.Received())
+	// The overhead starts when the worker took the message up, not while it was queued behind earlier messages of
+	// the connection. Their overhead is measured on their own.
+	since := message.Received()
+	if connWorker.lastDone.After(since) {
+		since = connWorker.lastDone
+	}
+	overhead := now.Sub(since) - held
+	connWorker.metrics.handling(messageType, actionName).Observe(handling.Seconds())
+	messageMetrics.overhead.Observe(overhead.Seconds())
+
+	if processor.noopBudget == 0 || actionType != protocol.ActionType_NOOP_ACTION_TYPE || overhead <= processor.noopBudget {
+		return
+	}
+	messageMetrics.budgetExceeded.Inc()
+	processor.budgetMutex.Lock()
+	defer processor.budgetMutex.Unlock()
+	processor.budgetExceeded++
+	if time.Since(processor.lastBudgetWarning) < budgetWarningInterval {
+		return
+	}
+	logger.With(daLogger.MessageType(messageType), daLogger.Duration(overhead)).Error("Handling noop took %s, exceeding the budget of %s for %d messages since the last warning, the DA distorts the results", overhead.String(), processor.noopBudget.String(), processor.budgetExceeded)
+	processor.budgetExceeded = 0
+	processor.lastBudgetWarning = time.Now()
+}
+
+func (processor *Processor) logFault(action setup.FaultAction, start time.Time, end time.Time, messageType string, node uint32, fields plugin.Fields) {
+	event := faultlog.Event{
+		Action:      action.Name(),
+		Node:        node,
+		Start:       start,
+		End:         end,
+		MessageType: messageType,
+	}
+	messageJson, err := fields.Json()
+	if err == nil {
+		event.Message = string(messageJson)
+	}
+
+	err = processor.faultLog.Write(event)
+	if err != nil {
+		logger.ErrorErr(err, "Failed to write '%s' fault event to fault log", action.Name())
+	}
+}
+
+func (processor *Processor) publish(kind string, messageType string, node uint32, fields plugin.Fields, action setup.FaultAction, duration time.Duration) {
+	event := events.Event{
+		Kind:        kind,
+		Timestamp:   time.Now(),
+		Node:        node,
+		MessageType: messageType,
+		Duration:    duration,
+	}
+	if action != nil {
+		event.Action = action.Name()
+	}
+	payload, err := fields.Json()
+	if err == nil {
+		event.Payload = payload
+	}
+
+	processor.eventHub.Publish(event)
+}
+
+// messageNode returns the node announced in the handshake of the connection or, for anonymous peers,
+// the node that reported the event.
+func messageNode(message network.Message, decoded proto.Message) uint32 {
+	if message.Peer().Identified || decoded == nil {
+		return message.Peer().NodeId
+	}
+	return reportingNode(decoded)
+}
+
+// reportingNode returns the ID of the node that reported the event, i.e. the node instrumented by this DA.
+func reportingNode(decoded proto.Message) uint32 {
+	switch m := decoded.(type) {
+	case *protocol.VoteRequestReceived:
+		return m.ReceivingNodeId
+	case *protocol.VoteReceived:
+		return m.VotedNodeId
+	case *protocol.LogEntryReplicated:
+		return m.ReceivingNodeId
+	case *protocol.LogEntryCommitted:
+		return m.ReceivingNodeId
+	case *protocol.LeaderSuspected:
+		return m.SuspectingNodeId
+	case *protocol.FollowerSuspected:
+		return m.LeaderId
+	case *protocol.PrePrepareReceived:
+		return m.ReceivingNodeId
+	case *protocol.PrepareReceived:
+		return m.ReceivingNodeId
+	case *protocol.CommitReceived:
+		return m.ReceivingNodeId
+	case *protocol.ViewChangeStarted:
+		return m.NodeId
+	case *protocol.CheckpointReached:
+		return m.NodeId
+	case *protocol.StateTransferRequested:
+		return m.RequestingNodeId
+	}
+
+	return 0
 }
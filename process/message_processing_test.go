@@ -0,0 +1,357 @@
+package process
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/events"
+	"github.com/FatProteins/master-thesis-code/metrics"
+	"github.com/FatProteins/master-thesis-code/network"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/operator"
+	"github.com/FatProteins/master-thesis-code/setup"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const haltDuration = 500 * time.Millisecond
+
+// startDA runs a network layer on a unix socket and a processor halting for haltDuration. It returns the path of
+// the socket.
+func startDA(t testing.TB) string {
+	t.Helper()
+	return startDAWithBudget(t, 0)
+}
+
+// startDAWithBudget runs the DA of startDA with the noop budget.
+func startDAWithBudget(t testing.TB, noopBudget time.Duration) string {
+	t.Helper()
+	return runTestDA(t, noopBudget).path
+}
+
+type testDA struct {
+	path        string
+	messageChan chan network.Message
+	processor   *Processor
+	breakpoints *operator.Breakpoints
+}
+
+func runTestDA(t testing.TB, noopBudget time.Duration) *testDA {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "da.sock")
+	transport, err := network.NewUnixTransport(network.SocketTypeStream, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messageChan := make(chan network.Message, 100)
+	networkLayer, err := network.NewNetworkLayer(messageChan, nil, transport, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := setup.FaultConfig{}
+	config.Actions.Halt.MaxDuration = int(haltDuration.Milliseconds())
+	breakpoints := operator.NewBreakpoints()
+	processor := NewProcessor(messageChan, nil, setup.NewActionPicker(config), nil, events.NewHub(), breakpoints, nil, nil)
+	processor.SetNoopBudget(noopBudget)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	networkLayer.RunAsync(ctx)
+	processor.RunAsync(ctx)
+	return &testDA{path: path, messageChan: messageChan, processor: processor, breakpoints: breakpoints}
+}
+
+type testNode struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// dial connects to the DA once it listens.
+func dial(t testing.TB, path string) net.Conn {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			t.Cleanup(func() { _ = conn.Close() })
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatal(err)
+		}
+	}
+}
+
+// connectNode dials the DA and identifies as the node.
+func connectNode(t *testing.T, path string, nodeId uint32) *testNode {
+	t.Helper()
+	conn := dial(t, path)
+
+	node := &testNode{t: t, conn: conn, reader: bufio.NewReader(conn)}
+	handshake, err := anypb.New(&protocol.Handshake{NodeId: nodeId, ProtocolName: "raft", Version: protocol.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.send(&protocol.Message{MessageType: protocol.MessageType_HANDSHAKE, MessageObject: handshake})
+	node.receive()
+	return node
+}
+
+func (node *testNode) send(message *protocol.Message) {
+	node.t.Helper()
+	messageBytes, err := proto.Marshal(message)
+	if err != nil {
+		node.t.Fatal(err)
+	}
+	_, err = node.conn.Write(append(binary.AppendUvarint(nil, uint64(len(messageBytes))), messageBytes...))
+	if err != nil {
+		node.t.Fatal(err)
+	}
+}
+
+func (node *testNode) receive() *protocol.Message {
+	node.t.Helper()
+	_ = node.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	size, err := binary.ReadUvarint(node.reader)
+	if err != nil {
+		node.t.Fatal(err)
+	}
+	messageBytes := make([]byte, size)
+	_, err = io.ReadFull(node.reader, messageBytes)
+	if err != nil {
+		node.t.Fatal(err)
+	}
+	response := &protocol.Message{}
+	err = proto.Unmarshal(messageBytes, response)
+	if err != nil {
+		node.t.Fatal(err)
+	}
+	return response
+}
+
+// report sends a vote the node received and requests the action.
+func (node *testNode) report(actionType protocol.ActionType) {
+	node.t.Helper()
+	vote, err := anypb.New(&protocol.VoteReceived{VotingNodeId: 2, VotedNodeId: 1, VoteGranted: true})
+	if err != nil {
+		node.t.Fatal(err)
+	}
+	node.send(&protocol.Message{MessageType: protocol.MessageType_VOTE_RECEIVED, ActionType: actionType, MessageObject: vote})
+}
+
+func TestHaltHoldsOnlyItsNode(t *testing.T) {
+	path := startDA(t)
+	halted := connectNode(t, path, 1)
+	other := connectNode(t, path, 2)
+
+	start := time.Now()
+	halted.report(protocol.ActionType_HALT_ACTION_TYPE)
+	time.Sleep(50 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		other.report(protocol.ActionType_NOOP_ACTION_TYPE)
+		response := other.receive()
+		if response.ActionType != protocol.ActionType_NOOP_ACTION_TYPE {
+			t.Fatalf("other node got '%s', want noop", response.ActionType.String())
+		}
+	}
+	if waited := time.Since(start); waited >= haltDuration {
+		t.Errorf("other node waited %s for its responses, it was held by the halt", waited)
+	}
+
+	response := halted.receive()
+	if response.ActionType != protocol.ActionType_HALT_ACTION_TYPE {
+		t.Fatalf("halted node got '%s', want halt", response.ActionType.String())
+	}
+	if waited := time.Since(start); waited < haltDuration {
+		t.Errorf("halted node got its response after %s, before the halt of %s ended", waited, haltDuration)
+	}
+}
+
+func TestResponsesOfConnectionStayInOrder(t *testing.T) {
+	path := startDA(t)
+	node := connectNode(t, path, 1)
+
+	node.report(protocol.ActionType_HALT_ACTION_TYPE)
+	node.report(protocol.ActionType_NOOP_ACTION_TYPE)
+	if actionType := node.receive().ActionType; actionType != protocol.ActionType_HALT_ACTION_TYPE {
+		t.Errorf("first response is '%s', want halt", actionType.String())
+	}
+	if actionType := node.receive().ActionType; actionType != protocol.ActionType_NOOP_ACTION_TYPE {
+		t.Errorf("second response is '%s', want noop", actionType.String())
+	}
+}
+
+// TestFullQueueBlocksReading holds the first message of a node at a breakpoint. Its connection queues only
+// workerQueueSize messages, the next one blocks dispatching and the rest stays unread.
+func TestFullQueueBlocksReading(t *testing.T) {
+	da := runTestDA(t, 0)
+	breakpoint := da.breakpoints.Add(operator.Breakpoint{MessageType: protocol.MessageType_VOTE_RECEIVED.String()})
+	node := connectNode(t, da.path, 1)
+
+	reports := workerQueueSize + 20
+	for i := 0; i < reports; i++ {
+		node.report(protocol.ActionType_NOOP_ACTION_TYPE)
+	}
+	for deadline := time.Now().Add(5 * time.Second); da.processor.Backlog() != workerQueueSize+1 || len(da.messageChan) != reports-workerQueueSize-2; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("backlog is %d with %d unread messages, want %d with %d unread", da.processor.Backlog(), len(da.messageChan), workerQueueSize+1, reports-workerQueueSize-2)
+		}
+	}
+
+	err := da.breakpoints.Remove(breakpoint.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, paused := range da.breakpoints.Paused() {
+		err = da.breakpoints.Resume(paused.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < reports; i++ {
+		node.receive()
+	}
+	if backlog := da.processor.Backlog(); backlog != 0 {
+		t.Errorf("backlog is %d after all responses, want 0", backlog)
+	}
+}
+
+func TestProcessorUpdatesMetrics(t *testing.T) {
+	path := startDA(t)
+	node := connectNode(t, path, 1)
+	received := metrics.MessagesReceived.WithLabelValues(protocol.MessageType_VOTE_RECEIVED.String())
+	halts := metrics.ActionsPerformed.WithLabelValues("Halt")
+	noops := metrics.ActionsPerformed.WithLabelValues("Noop")
+	unsupported := metrics.UnsupportedActions.WithLabelValues(protocol.ActionType_HALT_ACTION_TYPE.String())
+	before := []float64{testutil.ToFloat64(received), testutil.ToFloat64(halts), testutil.ToFloat64(noops), testutil.ToFloat64(unsupported)}
+
+	node.report(protocol.ActionType_HALT_ACTION_TYPE)
+	node.report(protocol.ActionType_NOOP_ACTION_TYPE)
+	node.receive()
+	node.receive()
+
+	// A node that only supports pauses gets noop instead of the halt.
+	pausing := &testNode{t: t, conn: dial(t, path)}
+	pausing.reader = bufio.NewReader(pausing.conn)
+	handshake, err := anypb.New(&protocol.Handshake{NodeId: 2, ProtocolName: "raft", Version: protocol.Version, SupportedActions: []protocol.ActionType{protocol.ActionType_PAUSE_ACTION_TYPE}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pausing.send(&protocol.Message{MessageType: protocol.MessageType_HANDSHAKE, MessageObject: handshake})
+	pausing.receive()
+	pausing.report(protocol.ActionType_HALT_ACTION_TYPE)
+	pausing.receive()
+
+	after := []float64{testutil.ToFloat64(received), testutil.ToFloat64(halts), testutil.ToFloat64(noops), testutil.ToFloat64(unsupported)}
+	names := []string{"received votes", "halts", "noops", "unsupported halts"}
+	for i, delta := range []float64{3, 1, 2, 1} {
+		if after[i]-before[i] != delta {
+			t.Errorf("%s increased by %v, want %v", names[i], after[i]-before[i], delta)
+		}
+	}
+	histograms := map[string]prometheus.Collector{
+		"da_handling_duration_seconds": metrics.HandlingDuration,
+		"da_handling_overhead_seconds": metrics.HandlingOverhead,
+		"da_action_duration_seconds":   metrics.ActionDuration,
+	}
+	for name, histogram := range histograms {
+		if series := testutil.CollectAndCount(histogram, name); series == 0 {
+			t.Errorf("'%s' has no series", name)
+		}
+	}
+}
+
+// TestQueuedTimeIsNoOverhead sends a noop right behind a halt of the same node. Waiting for the halt is no overhead
+// of the noop, so it stays within the budget.
+func TestQueuedTimeIsNoOverhead(t *testing.T) {
+	path := startDAWithBudget(t, 100*time.Millisecond)
+	node := connectNode(t, path, 1)
+	exceeded := metrics.NoopBudgetExceeded.WithLabelValues(protocol.MessageType_VOTE_RECEIVED.String())
+	before := testutil.ToFloat64(exceeded)
+
+	node.report(protocol.ActionType_HALT_ACTION_TYPE)
+	node.report(protocol.ActionType_NOOP_ACTION_TYPE)
+	node.receive()
+	node.receive()
+	if after := testutil.ToFloat64(exceeded); after != before {
+		t.Errorf("noop queued behind the halt exceeded the budget %v times", after-before)
+	}
+}
+
+// noopRoundTrip returns a function reporting a vote of the node and reading the noop response. Neither allocates,
+// so all allocations measured are those of the DA.
+func noopRoundTrip(t testing.TB, path string) func() {
+	t.Helper()
+	conn := dial(t, path)
+	reader := bufio.NewReader(conn)
+	buffer := make([]byte, 4096)
+	frame := func(message *protocol.Message) []byte {
+		messageBytes, err := proto.Marshal(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append(binary.AppendUvarint(nil, uint64(len(messageBytes))), messageBytes...)
+	}
+	read := func() {
+		size, err := binary.ReadUvarint(reader)
+		if err == nil {
+			_, err = io.ReadFull(reader, buffer[:size])
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	handshake, err := anypb.New(&protocol.Handshake{NodeId: 1, ProtocolName: "raft", Version: protocol.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Write(frame(&protocol.Message{MessageType: protocol.MessageType_HANDSHAKE, MessageObject: handshake}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read()
+
+	vote, err := anypb.New(&protocol.VoteReceived{VotingNodeId: 2, VotedNodeId: 1, VoteGranted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := frame(&protocol.Message{MessageType: protocol.MessageType_VOTE_RECEIVED, MessageObject: vote})
+	roundTrip := func() {
+		_, err := conn.Write(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read()
+	}
+	// Warm up the pools and caches.
+	for i := 0; i < 1000; i++ {
+		roundTrip()
+	}
+	return roundTrip
+}
+
+func TestNoopDoesNotAllocate(t *testing.T) {
+	roundTrip := noopRoundTrip(t, startDA(t))
+	if allocs := testing.AllocsPerRun(1000, roundTrip); allocs != 0 {
+		t.Errorf("%v allocations per noop message, want 0", allocs)
+	}
+}
+
+func BenchmarkNoop(b *testing.B) {
+	roundTrip := noopRoundTrip(b, startDA(b))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		roundTrip()
+	}
+}
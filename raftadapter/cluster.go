@@ -0,0 +1,166 @@
+// Package raftadapter runs a small cluster of etcd raft nodes in a single process and instruments it with the DA.
+// The nodes report their consensus events with the client package and apply the verdicts of the DA, so fault
+// experiments can run without Docker and without a fork of etcd.
+package raftadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/client"
+	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"io"
+	"log"
+	"time"
+)
+
+var logger = daLogger.NewLogger("raftadapter")
+
+const protocolName = "raft"
+
+type Config struct {
+	// Nodes is the number of nodes in the cluster, their IDs are 1 to Nodes.
+	Nodes int
+	// Client configures the connection of every node to the DA. NodeId and ProtocolName are set per node.
+	Client        client.Config
+	TickInterval  time.Duration
+	ElectionTick  int
+	HeartbeatTick int
+	// Apply is called for every committed normal entry on every node, if set. Nodes call it concurrently.
+	Apply func(nodeId uint64, index uint64, data []byte)
+}
+
+// Cluster is a set of raft nodes connected by an in-memory transport.
+type Cluster struct {
+	config Config
+	nodes  map[uint64]*Node
+	cancel context.CancelFunc
+}
+
+func NewCluster(config Config) (*Cluster, error) {
+	if config.Nodes <= 0 {
+		return nil, fmt.Errorf("cluster needs at least one node, but has %d", config.Nodes)
+	}
+	if config.TickInterval == 0 {
+		config.TickInterval = 100 * time.Millisecond
+	}
+	if config.ElectionTick == 0 {
+		config.ElectionTick = 10
+	}
+	if config.HeartbeatTick == 0 {
+		config.HeartbeatTick = 1
+	}
+
+	cluster := &Cluster{config: config, nodes: make(map[uint64]*Node, config.Nodes)}
+	peers := make([]raft.Peer, 0, config.Nodes)
+	for id := uint64(1); id <= uint64(config.Nodes); id++ {
+		peers = append(peers, raft.Peer{ID: id})
+	}
+
+	for id := uint64(1); id <= uint64(config.Nodes); id++ {
+		clientConfig := config.Client
+		clientConfig.NodeId = uint32(id)
+		clientConfig.ProtocolName = protocolName
+		daClient, err := client.NewClient(clientConfig)
+		if err != nil {
+			cluster.closeClients()
+			return nil, err
+		}
+
+		node := &Node{
+			id:        id,
+			cluster:   cluster,
+			storage:   raft.NewMemoryStorage(),
+			daClient:  daClient,
+			inbox:     make(chan raftpb.Message, 1024),
+			proposals: make(chan []byte, 1024),
+		}
+		node.raftNode = raft.StartNode(node.raftConfig(), peers)
+		cluster.nodes[id] = node
+	}
+
+	return cluster, nil
+}
+
+// RunAsync starts ticking the nodes and delivering their messages.
+func (cluster *Cluster) RunAsync(ctx context.Context) {
+	ctx, cluster.cancel = context.WithCancel(ctx)
+	for _, node := range cluster.nodes {
+		go node.run(ctx)
+	}
+}
+
+// Leader returns the ID of the node the majority of nodes follows or 0 if there is none.
+func (cluster *Cluster) Leader() uint64 {
+	votes := make(map[uint64]int)
+	for _, node := range cluster.nodes {
+		lead := node.lead.Load()
+		votes[lead]++
+		if lead != raft.None && votes[lead] > len(cluster.nodes)/2 {
+			return lead
+		}
+	}
+
+	return raft.None
+}
+
+// Propose hands data to the current leader to append it to the log.
+func (cluster *Cluster) Propose(data []byte) error {
+	leader := cluster.Leader()
+	if leader == raft.None {
+		return errors.New("cluster has no leader")
+	}
+
+	select {
+	case cluster.nodes[leader].proposals <- data:
+		return nil
+	default:
+		return fmt.Errorf("proposal queue of node %d is full", leader)
+	}
+}
+
+// Node returns the node with the given ID or nil.
+func (cluster *Cluster) Node(id uint64) *Node {
+	return cluster.nodes[id]
+}
+
+// InjectFault requests an action for the next report of a received message or committed entry of the node. The
+// ActionPicker picks the action requested by the report, so the DA performs it.
+func (cluster *Cluster) InjectFault(nodeId uint64, actionType protocol.ActionType) {
+	node, ok := cluster.nodes[nodeId]
+	if ok {
+		node.injectFault(actionType)
+	}
+}
+
+func (cluster *Cluster) Stop() {
+	if cluster.cancel != nil {
+		cluster.cancel()
+	}
+	cluster.closeClients()
+}
+
+func (cluster *Cluster) closeClients() {
+	for _, node := range cluster.nodes {
+		_ = node.daClient.Close()
+	}
+}
+
+// send is the in-memory transport. Messages to nodes whose inbox is full are dropped, like on a lossy network.
+func (cluster *Cluster) send(message raftpb.Message) {
+	node, ok := cluster.nodes[message.To]
+	if !ok {
+		return
+	}
+
+	select {
+	case node.inbox <- message:
+	default:
+		logger.Debug("Dropping %s to node %d", message.Type.String(), message.To)
+	}
+}
+
+var discardLogger = &raft.DefaultLogger{Logger: log.New(io.Discard, "", 0)}
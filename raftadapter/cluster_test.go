@@ -0,0 +1,103 @@
+package raftadapter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/client"
+	"github.com/FatProteins/master-thesis-code/network"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/setup"
+)
+
+// startCluster runs a DA in-process, without any container commands, and a cluster of three nodes connected to it.
+func startCluster(t *testing.T, faultConfig setup.FaultConfig) *Cluster {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "da.sock")
+	transport, err := network.NewUnixTransport(network.SocketTypeStream, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	err = RunDA(ctx, transport, faultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster, err := NewCluster(Config{
+		Nodes:        3,
+		Client:       client.Config{Transport: client.TransportUnix, Address: path, Timeout: 5 * time.Second},
+		TickInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cluster.Stop)
+	cluster.RunAsync(ctx)
+	return cluster
+}
+
+func waitForLeader(t *testing.T, cluster *Cluster) uint64 {
+	t.Helper()
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if leader := cluster.Leader(); leader != 0 {
+			return leader
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no leader elected within 10s")
+		}
+	}
+}
+
+func TestClusterElectsLeader(t *testing.T) {
+	cluster := startCluster(t, setup.FaultConfig{})
+	leader := waitForLeader(t, cluster)
+	if cluster.Node(leader) == nil {
+		t.Fatalf("leader %d is no node of the cluster", leader)
+	}
+
+	err := cluster.Propose([]byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(10 * time.Second); cluster.Node(leader).Committed() < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("proposal not committed within 10s, committed index is %d", cluster.Node(leader).Committed())
+		}
+	}
+}
+
+func TestStoppingLeaderTriggersReelection(t *testing.T) {
+	faultConfig := setup.FaultConfig{}
+	faultConfig.Actions.Stop.MaxDuration = 1000
+	cluster := startCluster(t, faultConfig)
+	leader := waitForLeader(t, cluster)
+
+	// The leader reports the commit of the proposal and is stopped.
+	cluster.InjectFault(leader, protocol.ActionType_STOP_ACTION_TYPE)
+	err := cluster.Propose([]byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var newLeader uint64
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		newLeader = cluster.Leader()
+		if newLeader != 0 && newLeader != leader {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no new leader within 10s after stopping leader %d", leader)
+		}
+	}
+
+	// The stopped node restarts and follows the new leader, which commits the proposal again.
+	stopped := cluster.Node(leader)
+	for deadline := time.Now().Add(10 * time.Second); stopped.Restarts() != 1 || stopped.lead.Load() != newLeader || stopped.Committed() < 2; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("stopped node has %d restarts, follows %d and committed index %d, want it restarted once following %d", stopped.Restarts(), stopped.lead.Load(), stopped.Committed(), newLeader)
+		}
+	}
+}
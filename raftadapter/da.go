@@ -0,0 +1,74 @@
+package raftadapter
+
+import (
+	"context"
+	"github.com/FatProteins/master-thesis-code/constants"
+	"github.com/FatProteins/master-thesis-code/events"
+	"github.com/FatProteins/master-thesis-code/network"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/process"
+	"github.com/FatProteins/master-thesis-code/setup"
+	"time"
+)
+
+// RunDA runs a DA for the cluster in this process until the context is done. Its actions need no container
+// commands, so the fault config needs none either: a node waiting for its verdict is already halted, so halts and
+// pauses only wait for their duration, and a stop keeps the node down for its duration and then resets the
+// connection of the node, which restarts it.
+func RunDA(ctx context.Context, transport network.Transport, faultConfig setup.FaultConfig) error {
+	maxMessageSize := faultConfig.MaxMessageSize
+	if maxMessageSize == 0 {
+		maxMessageSize = constants.DefaultMaxMessageSize
+	}
+
+	msgChan := make(chan network.Message, 1024)
+	networkLayer, err := network.NewNetworkLayer(msgChan, nil, transport, maxMessageSize)
+	if err != nil {
+		return err
+	}
+	decider := &inProcessDecider{ActionPicker: setup.NewActionPicker(faultConfig)}
+	processor := process.NewProcessor(msgChan, nil, decider, nil, events.NewHub(), nil, nil, nil)
+
+	networkLayer.RunAsync(ctx)
+	processor.RunAsync(ctx)
+	logger.Info("Running DA on '%s'", transport.Address())
+	return nil
+}
+
+// inProcessDecider lets the ActionPicker decide, but performs the actions on the nodes of this process.
+type inProcessDecider struct {
+	*setup.ActionPicker
+}
+
+func (decider *inProcessDecider) GetAction(actionType protocol.ActionType) setup.FaultAction {
+	return &inProcessAction{FaultAction: decider.ActionPicker.GetAction(actionType), actionType: actionType}
+}
+
+type inProcessAction struct {
+	setup.FaultAction
+	actionType protocol.ActionType
+}
+
+func (action *inProcessAction) Perform(ctx context.Context, node uint32, duration time.Duration, resetConnFunc func()) {
+	switch action.actionType {
+	case protocol.ActionType_HALT_ACTION_TYPE, protocol.ActionType_PAUSE_ACTION_TYPE:
+		wait(ctx, duration)
+	case protocol.ActionType_STOP_ACTION_TYPE:
+		logger.Info("Stopping node %d for %s", node, duration.String())
+		if wait(ctx, duration) {
+			resetConnFunc()
+		}
+	}
+}
+
+// wait returns false if the context is done before the duration is over.
+func wait(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
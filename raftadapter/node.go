@@ -0,0 +1,231 @@
+package raftadapter
+
+import (
+	"context"
+	"errors"
+	"github.com/FatProteins/master-thesis-code/client"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"go.etcd.io/etcd/raft/v3"
+	"go.etcd.io/etcd/raft/v3/raftpb"
+	"google.golang.org/protobuf/proto"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Node is a raft node instrumented with the DA. All of its work happens on a single goroutine, so while it waits
+// for a verdict of the DA, it neither ticks nor handles messages, i.e. it is halted.
+type Node struct {
+	id        uint64
+	cluster   *Cluster
+	raftNode  raft.Node
+	storage   *raft.MemoryStorage
+	daClient  *client.Client
+	inbox     chan raftpb.Message
+	proposals chan []byte
+	lead      atomic.Uint64
+	committed atomic.Uint64
+	restarts  atomic.Int64
+
+	faultsMutex sync.Mutex
+	faults      []protocol.ActionType
+}
+
+func (node *Node) Id() uint64 {
+	return node.id
+}
+
+// Committed returns the index of the last entry committed by the node.
+func (node *Node) Committed() uint64 {
+	return node.committed.Load()
+}
+
+// Restarts returns how often the node was stopped by the DA.
+func (node *Node) Restarts() int64 {
+	return node.restarts.Load()
+}
+
+func (node *Node) raftConfig() *raft.Config {
+	return &raft.Config{
+		ID:              node.id,
+		Applied:         node.committed.Load(),
+		ElectionTick:    node.cluster.config.ElectionTick,
+		HeartbeatTick:   node.cluster.config.HeartbeatTick,
+		Storage:         node.storage,
+		MaxSizePerMsg:   1024 * 1024,
+		MaxInflightMsgs: 256,
+		Logger:          discardLogger,
+	}
+}
+
+func (node *Node) run(ctx context.Context) {
+	ticker := time.NewTicker(node.cluster.config.TickInterval)
+	defer ticker.Stop()
+	defer func() {
+		node.raftNode.Stop()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			node.raftNode.Tick()
+		case data := <-node.proposals:
+			err := node.raftNode.Propose(ctx, data)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				logger.ErrorErr(err, "Node %d failed to propose", node.id)
+			}
+		case message := <-node.inbox:
+			node.receive(ctx, message)
+		case ready := <-node.raftNode.Ready():
+			node.handleReady(ready)
+		}
+	}
+}
+
+// receive reports the message to the DA and applies its verdict before the node steps it.
+func (node *Node) receive(ctx context.Context, message raftpb.Message) {
+	switch node.verdict(node.report(message)) {
+	case protocol.ActionType_STOP_ACTION_TYPE:
+		node.restart()
+		return
+	case protocol.ActionType_RESEND_LAST_MESSAGE_ACTION_TYPE:
+		_ = node.raftNode.Step(ctx, message)
+	}
+
+	// Halts and pauses are already over, the DA responds after performing them.
+	_ = node.raftNode.Step(ctx, message)
+}
+
+// report sends the consensus event of a received message to the DA. Messages without event are not reported.
+func (node *Node) report(message raftpb.Message) (protocol.ActionType, error) {
+	var messageType protocol.MessageType
+	var event proto.Message
+	switch message.Type {
+	case raftpb.MsgVote:
+		messageType = protocol.MessageType_VOTE_REQUEST_RECEIVED
+		event = &protocol.VoteRequestReceived{RequestingNodeId: uint32(message.From), ReceivingNodeId: uint32(message.To), Term: message.Term}
+	case raftpb.MsgVoteResp:
+		messageType = protocol.MessageType_VOTE_RECEIVED
+		event = &protocol.VoteReceived{VotingNodeId: uint32(message.From), VotedNodeId: uint32(message.To), VoteGranted: !message.Reject}
+	case raftpb.MsgApp:
+		if len(message.Entries) == 0 {
+			return protocol.ActionType_NOOP_ACTION_TYPE, nil
+		}
+		lastIndex := message.Entries[len(message.Entries)-1].Index
+		messageType = protocol.MessageType_LOG_ENTRY_REPLICATED
+		event = &protocol.LogEntryReplicated{LeaderId: uint32(message.From), ReceivingNodeId: uint32(message.To), LogEntryNumber: int64(lastIndex)}
+	default:
+		return protocol.ActionType_NOOP_ACTION_TYPE, nil
+	}
+
+	return node.daClient.ReportAction(messageType, event, node.nextFault())
+}
+
+// verdict returns the action the DA performed for a report. The DA resets the connection when it stops the node.
+func (node *Node) verdict(actionType protocol.ActionType, err error) protocol.ActionType {
+	if errors.Is(err, client.ErrConnectionLost) {
+		return protocol.ActionType_STOP_ACTION_TYPE
+	}
+	if err != nil {
+		logger.Debug("Node %d continues without verdict: %s", node.id, err.Error())
+	}
+	return actionType
+}
+
+// injectFault requests an action for the next report of a received message or committed entry.
+func (node *Node) injectFault(actionType protocol.ActionType) {
+	node.faultsMutex.Lock()
+	defer node.faultsMutex.Unlock()
+	node.faults = append(node.faults, actionType)
+}
+
+func (node *Node) nextFault() protocol.ActionType {
+	node.faultsMutex.Lock()
+	defer node.faultsMutex.Unlock()
+	if len(node.faults) == 0 {
+		return protocol.ActionType_NOOP_ACTION_TYPE
+	}
+	actionType := node.faults[0]
+	node.faults = node.faults[1:]
+	return actionType
+}
+
+func (node *Node) handleReady(ready raft.Ready) {
+	if ready.SoftState != nil {
+		node.updateLeader(ready.SoftState)
+	}
+
+	if !raft.IsEmptySnap(ready.Snapshot) {
+		_ = node.storage.ApplySnapshot(ready.Snapshot)
+	}
+	if !raft.IsEmptyHardState(ready.HardState) {
+		_ = node.storage.SetHardState(ready.HardState)
+	}
+	_ = node.storage.Append(ready.Entries)
+
+	for _, message := range ready.Messages {
+		node.cluster.send(message)
+	}
+
+	for _, entry := range ready.CommittedEntries {
+		if node.apply(entry) == protocol.ActionType_STOP_ACTION_TYPE {
+			node.restart()
+			return
+		}
+	}
+
+	node.raftNode.Advance()
+}
+
+func (node *Node) updateLeader(softState *raft.SoftState) {
+	previousLead := node.lead.Swap(softState.Lead)
+	if softState.RaftState == raft.StateCandidate && previousLead != raft.None && previousLead != node.id {
+		_, _ = node.daClient.ReportLeaderSuspected(uint32(previousLead), uint32(node.id))
+	}
+}
+
+// apply reports a committed entry to the DA and applies it. A node stopped by the DA does not apply the entry, it
+// is committed again after the restart.
+func (node *Node) apply(entry raftpb.Entry) protocol.ActionType {
+	switch entry.Type {
+	case raftpb.EntryNormal:
+		// Empty entries are appended by new leaders.
+		if len(entry.Data) > 0 {
+			event := &protocol.LogEntryCommitted{LeaderId: uint32(node.lead.Load()), ReceivingNodeId: uint32(node.id), LogEntryNumber: int64(entry.Index)}
+			actionType := node.verdict(node.daClient.ReportAction(protocol.MessageType_LOG_ENTRY_COMMITTED, event, node.nextFault()))
+			if actionType == protocol.ActionType_STOP_ACTION_TYPE {
+				return actionType
+			}
+			if node.cluster.config.Apply != nil {
+				node.cluster.config.Apply(node.id, entry.Index, entry.Data)
+			}
+		}
+	case raftpb.EntryConfChange:
+		var confChange raftpb.ConfChange
+		err := confChange.Unmarshal(entry.Data)
+		if err != nil {
+			logger.ErrorErr(err, "Node %d failed to decode conf change", node.id)
+		} else {
+			node.raftNode.ApplyConfChange(confChange)
+		}
+	}
+
+	node.committed.Store(entry.Index)
+	return protocol.ActionType_NOOP_ACTION_TYPE
+}
+
+// restart stops the node, dropping all messages it has not handled yet, and restarts it from its storage
+// like a crashed process.
+func (node *Node) restart() {
+	logger.Info("Node %d stopped by DA, restarting", node.id)
+	node.restarts.Add(1)
+	node.raftNode.Stop()
+	node.lead.Store(raft.None)
+	for len(node.inbox) > 0 {
+		<-node.inbox
+	}
+
+	node.raftNode = raft.RestartNode(node.raftConfig())
+}
@@ -0,0 +1,96 @@
+package replay
+
+import (
+	"bufio"
+	"errors"
+	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/setup"
+	"os"
+	"sync"
+	"time"
+)
+
+var logger = daLogger.NewLogger("replay")
+
+// flushInterval is how often the recorder writes buffered records to the trace file.
+const flushInterval = time.Second
+
+// Recorder writes every decision of the wrapped ActionDecider to a trace file. Records are buffered and flushed
+// every flushInterval and on Close instead of per decision, which would add a write syscall to every message. If
+// the DA crashes, the records of the last flushInterval are lost.
+type Recorder struct {
+	setup.ActionDecider
+	mutex    sync.Mutex
+	file     *os.File
+	writer   *bufio.Writer
+	sequence uint64
+	done     chan struct{}
+	flushed  chan struct{}
+}
+
+func NewRecorder(decider setup.ActionDecider, path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(file)
+	_, err = writer.WriteString(traceMagic)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	recorder := &Recorder{ActionDecider: decider, file: file, writer: writer, done: make(chan struct{}), flushed: make(chan struct{})}
+	go recorder.flushLoop()
+	return recorder, nil
+}
+
+func (recorder *Recorder) flushLoop() {
+	defer close(recorder.flushed)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-recorder.done:
+			return
+		case <-ticker.C:
+			recorder.mutex.Lock()
+			err := recorder.writer.Flush()
+			recorder.mutex.Unlock()
+			if err != nil {
+				logger.ErrorErr(err, "Failed to flush trace")
+			}
+		}
+	}
+}
+
+func (recorder *Recorder) Decide(message *protocol.Message) setup.Decision {
+	decision := recorder.ActionDecider.Decide(message)
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.sequence++
+	err := writeRecord(recorder.writer, Record{
+		Sequence:  recorder.sequence,
+		Timestamp: time.Now(),
+		Decision:  decision,
+		Message:   message,
+	})
+	if err != nil {
+		logger.ErrorErr(err, "Failed to record decision for message %d", recorder.sequence)
+	}
+
+	return decision
+}
+
+// Close flushes the remaining records and closes the trace file.
+func (recorder *Recorder) Close() error {
+	close(recorder.done)
+	<-recorder.flushed
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	return errors.Join(recorder.writer.Flush(), recorder.file.Close())
+}
@@ -0,0 +1,160 @@
+package replay
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/setup"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// testPicker samples the durations of halts up to a second with a fixed seed.
+func testPicker(seed int64) *setup.ActionPicker {
+	config := setup.FaultConfig{}
+	config.Actions.RandomDurations = true
+	config.Actions.Seed = seed
+	config.Actions.Halt.MaxDuration = 1000
+	return setup.NewActionPicker(config)
+}
+
+func testMessage(t *testing.T, term uint64) *protocol.Message {
+	t.Helper()
+	messageObject, err := anypb.New(&protocol.VoteRequestReceived{RequestingNodeId: 1, ReceivingNodeId: 2, Term: term})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &protocol.Message{MessageType: protocol.MessageType_VOTE_REQUEST_RECEIVED, ActionType: protocol.ActionType_HALT_ACTION_TYPE, MessageObject: messageObject}
+}
+
+// record decides the messages of the terms with a recorder and returns the path of the trace and the decisions.
+func record(t *testing.T, terms ...uint64) (string, []setup.Decision) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "trace.bin")
+	recorder, err := NewRecorder(testPicker(1), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decisions := make([]setup.Decision, 0, len(terms))
+	for _, term := range terms {
+		decisions = append(decisions, recorder.Decide(testMessage(t, term)))
+	}
+	err = recorder.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, decisions
+}
+
+// TestRecorderFlushesPeriodically reads the trace while the recorder is still open. The decisions are written
+// within flushInterval, not only on Close.
+func TestRecorderFlushesPeriodically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.bin")
+	recorder, err := NewRecorder(testPicker(1), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recorder.Close()
+	recorder.Decide(testMessage(t, 1))
+	recorder.Decide(testMessage(t, 2))
+
+	for deadline := time.Now().Add(3 * flushInterval); ; time.Sleep(50 * time.Millisecond) {
+		records, err := ReadTrace(path)
+		if err == nil && len(records) == 2 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("trace has %d records after %s, want 2: %v", len(records), 3*flushInterval, err)
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path, recorded := record(t, 1, 2, 3, 4, 5)
+
+	records, err := ReadTrace(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(recorded) {
+		t.Fatalf("trace has %d records, want %d", len(records), len(recorded))
+	}
+	for i, record := range records {
+		if record.Sequence != uint64(i+1) || record.Decision != recorded[i] || record.Timestamp.IsZero() {
+			t.Errorf("record %d is %+v, want sequence %d and decision %+v", i, record, i+1, recorded[i])
+		}
+	}
+
+	// The replayer samples with another seed, only the trace may decide.
+	replayer, err := NewReplayer(testPicker(2), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, term := range []uint64{1, 2, 3, 4, 5} {
+		decision := replayer.Decide(testMessage(t, term))
+		if decision != recorded[i] {
+			t.Errorf("message %d: replayed %+v, want %+v", i+1, decision, recorded[i])
+		}
+	}
+	if replayer.Divergences() != 0 || replayer.Remaining() != 0 {
+		t.Errorf("got %d divergences and %d remaining decisions, want none", replayer.Divergences(), replayer.Remaining())
+	}
+}
+
+func TestSampledDurations(t *testing.T) {
+	_, recorded := record(t, 1, 2, 3, 4, 5)
+	distinct := make(map[time.Duration]struct{})
+	for _, decision := range recorded {
+		if decision.Duration < 0 || decision.Duration > time.Second {
+			t.Errorf("sampled %s, want at most 1s", decision.Duration.String())
+		}
+		distinct[decision.Duration] = struct{}{}
+	}
+	if len(distinct) < 2 {
+		t.Errorf("all durations are %s, want them sampled", recorded[0].Duration.String())
+	}
+
+	_, again := record(t, 1, 2, 3, 4, 5)
+	for i := range recorded {
+		if again[i] != recorded[i] {
+			t.Errorf("decision %d is %+v with the same seed, want %+v", i+1, again[i], recorded[i])
+		}
+	}
+}
+
+func TestReplayDivergence(t *testing.T) {
+	path, recorded := record(t, 1, 2, 3, 4, 5)
+	replayer, err := NewReplayer(testPicker(1), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	noop := setup.Decision{ActionType: protocol.ActionType_NOOP_ACTION_TYPE}
+
+	for _, step := range []struct {
+		term        uint64
+		expected    setup.Decision
+		divergences int
+	}{
+		{1, recorded[0], 0},
+		// An added message is not in the trace, no recorded decision is replayed for it.
+		{99, noop, 1},
+		{2, recorded[1], 1},
+		// Message 3 is missing, replay continues with message 4.
+		{4, recorded[3], 2},
+		{5, recorded[4], 2},
+		// The trace is exhausted.
+		{6, noop, 3},
+	} {
+		decision := replayer.Decide(testMessage(t, step.term))
+		if decision != step.expected {
+			t.Errorf("term %d: got %+v, want %+v", step.term, decision, step.expected)
+		}
+		if divergences := replayer.Divergences(); divergences != step.divergences {
+			t.Errorf("term %d: got %d divergences, want %d", step.term, divergences, step.divergences)
+		}
+	}
+	if remaining := replayer.Remaining(); remaining != 0 {
+		t.Errorf("%d decisions remaining, want 0", remaining)
+	}
+}
@@ -0,0 +1,121 @@
+package replay
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/setup"
+	"github.com/FatProteins/master-thesis-code/util"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"os"
+	"sync"
+)
+
+// Replayer replaces the decisions of an ActionDecider with the decisions of a recorded trace.
+// Every incoming message is compared with the next recorded message and any difference is reported
+// as divergence, see Decide.
+type Replayer struct {
+	setup.ActionDecider
+	mutex       sync.Mutex
+	records     []Record
+	next        int
+	received    uint64
+	divergences int
+}
+
+// resyncWindow is how many records a diverging message is looked up in, to continue after messages that were
+// missing or added.
+const resyncWindow = 100
+
+func NewReplayer(decider setup.ActionDecider, path string) (*Replayer, error) {
+	records, err := ReadTrace(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{ActionDecider: decider, records: records}, nil
+}
+
+// ReadTrace returns all records of a trace file written by Recorder.
+func ReadTrace(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	magic := make([]byte, len(traceMagic))
+	_, err = io.ReadFull(reader, magic)
+	if err != nil || string(magic) != traceMagic {
+		return nil, fmt.Errorf("'%s' is not a DA trace file", path)
+	}
+
+	var records []Record
+	for {
+		record, err := readRecord(reader)
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("could not read trace '%s'", path))
+		}
+		records = append(records, record)
+	}
+}
+
+// Decide replays the decision recorded for the message. A message that does not match the next recorded message
+// is looked up in the following resyncWindow records: if found, the records skipped are a divergence and replay
+// continues from there, otherwise the message is a divergence and answered with Noop. A recorded decision is never
+// replayed for a different message.
+func (replayer *Replayer) Decide(message *protocol.Message) setup.Decision {
+	replayer.mutex.Lock()
+	defer replayer.mutex.Unlock()
+
+	replayer.received++
+	end := util.Min(replayer.next+resyncWindow, len(replayer.records))
+	for i := replayer.next; i < end; i++ {
+		record := replayer.records[i]
+		if !proto.Equal(record.Message, message) {
+			continue
+		}
+
+		if skipped := i - replayer.next; skipped > 0 {
+			replayer.divergences++
+			logger.Error("Divergence at message %d: recorded messages %d to %d were not received, continuing with recorded message %d",
+				replayer.received, replayer.records[replayer.next].Sequence, replayer.records[i-1].Sequence, record.Sequence)
+		}
+		replayer.next = i + 1
+		logger.Debug("Replaying decision %d: '%s' for %s", record.Sequence, record.Decision.ActionType.String(), record.Decision.Duration.String())
+		return record.Decision
+	}
+
+	replayer.divergences++
+	if replayer.next == len(replayer.records) {
+		logger.Error("Divergence at message %d: trace with %d records exhausted, received additional '%s' message. Falling back to Noop.",
+			replayer.received, len(replayer.records), message.MessageType.String())
+	} else {
+		logger.Error("Divergence at message %d: expected '%s' but received '%s', which is not in the next %d records. Falling back to Noop.",
+			replayer.received, replayer.records[replayer.next].Message.String(), message.String(), resyncWindow)
+	}
+	return setup.Decision{ActionType: protocol.ActionType_NOOP_ACTION_TYPE}
+}
+
+// Divergences returns the number of divergences so far: messages not in the trace and runs of recorded messages
+// that were not received.
+func (replayer *Replayer) Divergences() int {
+	replayer.mutex.Lock()
+	defer replayer.mutex.Unlock()
+
+	return replayer.divergences
+}
+
+// Remaining returns the number of recorded decisions that have not been replayed yet.
+func (replayer *Replayer) Remaining() int {
+	replayer.mutex.Lock()
+	defer replayer.mutex.Unlock()
+
+	return len(replayer.records) - replayer.next
+}
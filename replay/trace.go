@@ -0,0 +1,108 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/setup"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"time"
+)
+
+// traceMagic is written at the start of every trace file.
+const traceMagic = "DATRACE1"
+
+// Record is a single decision of the DA in a trace.
+type Record struct {
+	Sequence  uint64
+	Timestamp time.Time
+	Decision  setup.Decision
+	Message   *protocol.Message
+}
+
+var marshalOptions = proto.MarshalOptions{Deterministic: true}
+
+// A record is encoded as uvarint length followed by the uvarint sequence number, varint timestamp,
+// uvarint action type, varint duration and the deterministically marshalled message.
+func writeRecord(writer *bufio.Writer, record Record) error {
+	messageBytes, err := marshalOptions.Marshal(record.Message)
+	if err != nil {
+		return err
+	}
+
+	body := make([]byte, 0, 4*binary.MaxVarintLen64+len(messageBytes))
+	body = binary.AppendUvarint(body, record.Sequence)
+	body = binary.AppendVarint(body, record.Timestamp.UnixNano())
+	body = binary.AppendUvarint(body, uint64(record.Decision.ActionType))
+	body = binary.AppendVarint(body, int64(record.Decision.Duration))
+	body = append(body, messageBytes...)
+
+	lengthBuf := binary.AppendUvarint(nil, uint64(len(body)))
+	_, err = writer.Write(lengthBuf)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(body)
+	return err
+}
+
+func readRecord(reader *bufio.Reader) (Record, error) {
+	var record Record
+	length, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return record, err
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(reader, body)
+	if err != nil {
+		return record, errors.Join(err, errors.New("truncated trace record"))
+	}
+
+	offset := 0
+	nextUvarint := func() (uint64, error) {
+		value, n := binary.Uvarint(body[offset:])
+		if n <= 0 {
+			return 0, fmt.Errorf("malformed trace record at offset %d", offset)
+		}
+		offset += n
+		return value, nil
+	}
+	nextVarint := func() (int64, error) {
+		value, n := binary.Varint(body[offset:])
+		if n <= 0 {
+			return 0, fmt.Errorf("malformed trace record at offset %d", offset)
+		}
+		offset += n
+		return value, nil
+	}
+
+	if record.Sequence, err = nextUvarint(); err != nil {
+		return record, err
+	}
+	timestamp, err := nextVarint()
+	if err != nil {
+		return record, err
+	}
+	actionType, err := nextUvarint()
+	if err != nil {
+		return record, err
+	}
+	duration, err := nextVarint()
+	if err != nil {
+		return record, err
+	}
+
+	record.Timestamp = time.Unix(0, timestamp)
+	record.Decision = setup.Decision{ActionType: protocol.ActionType(actionType), Duration: time.Duration(duration)}
+	record.Message = &protocol.Message{}
+	err = proto.Unmarshal(body[offset:], record.Message)
+	if err != nil {
+		return record, errors.Join(err, fmt.Errorf("could not unmarshal message of trace record %d", record.Sequence))
+	}
+
+	return record, nil
+}
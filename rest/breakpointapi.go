@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"github.com/FatProteins/master-thesis-code/operator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// BreakpointApi manages breakpoints and resumes messages paused by them.
+func BreakpointApi(router gin.IRouter, breakpoints *operator.Breakpoints) {
+	router.GET("/breakpoints", func(context *gin.Context) {
+		context.JSON(http.StatusOK, breakpoints.List())
+	})
+	router.POST("/breakpoints", func(context *gin.Context) {
+		var breakpoint operator.Breakpoint
+		if err := context.BindJSON(&breakpoint); err != nil {
+			logger.ErrorErr(err, "Could not read breakpoint entity")
+			return
+		}
+		breakpoint.MessageType = strings.ToUpper(breakpoint.MessageType)
+		context.JSON(http.StatusCreated, breakpoints.Add(breakpoint))
+	})
+	router.DELETE("/breakpoints/:id", func(context *gin.Context) {
+		id, ok := parseId(context)
+		if !ok {
+			return
+		}
+		if err := breakpoints.Remove(id); err != nil {
+			context.String(http.StatusNotFound, err.Error())
+			return
+		}
+		context.Status(http.StatusNoContent)
+	})
+	router.GET("/breakpoints/paused", func(context *gin.Context) {
+		context.JSON(http.StatusOK, breakpoints.Paused())
+	})
+	router.POST("/breakpoints/paused/:id/resume", func(context *gin.Context) {
+		id, ok := parseId(context)
+		if !ok {
+			return
+		}
+		if err := breakpoints.Resume(id); err != nil {
+			context.String(http.StatusNotFound, err.Error())
+			return
+		}
+		context.Status(http.StatusNoContent)
+	})
+}
+
+func parseId(context *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(context.Param("id"), 10, 64)
+	if err != nil {
+		context.String(http.StatusBadRequest, "invalid id '%s'", context.Param("id"))
+		return 0, false
+	}
+	return id, true
+}
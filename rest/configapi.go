@@ -6,10 +6,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-var logger = daLogger.NewLogger("configapi")
+var logger = daLogger.NewLogger("rest")
 
-func ConfigApi() {
-	router := gin.Default()
+func ConfigApi(router gin.IRouter) {
 	router.POST("/config/update", updateConfig)
 }
 
@@ -0,0 +1,14 @@
+package rest
+
+import (
+	"github.com/FatProteins/master-thesis-code/network"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// ConnectionsApi exposes the state of the DA socket and the connected nodes.
+func ConnectionsApi(router gin.IRouter, networkLayer *network.NetworkLayer) {
+	router.GET("/connections", func(context *gin.Context) {
+		context.JSON(http.StatusOK, networkLayer.Status())
+	})
+}
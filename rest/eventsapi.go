@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"github.com/FatProteins/master-thesis-code/events"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// EventsApi streams events as Server-Sent Events on GET /events.
+// Clients can filter by repeating the query parameters 'node' and 'type', e.g. /events?node=1&type=VOTE_RECEIVED.
+func EventsApi(router gin.IRouter, hub *events.Hub) {
+	router.GET("/events", func(context *gin.Context) {
+		streamEvents(context, hub)
+	})
+}
+
+func streamEvents(context *gin.Context, hub *events.Hub) {
+	filter := events.Filter{Nodes: make(map[uint32]bool), MessageTypes: make(map[string]bool)}
+	for _, node := range context.QueryArray("node") {
+		nodeId, err := strconv.ParseUint(node, 10, 32)
+		if err != nil {
+			context.String(http.StatusBadRequest, "invalid node '%s'", node)
+			return
+		}
+		filter.Nodes[uint32(nodeId)] = true
+	}
+	for _, messageType := range context.QueryArray("type") {
+		filter.MessageTypes[strings.ToUpper(messageType)] = true
+	}
+
+	subscription := hub.Subscribe(filter)
+	defer hub.Unsubscribe(subscription)
+	logger.Info("Event stream client '%s' connected", context.ClientIP())
+
+	context.Stream(func(w io.Writer) bool {
+		select {
+		case <-context.Request.Context().Done():
+			return false
+		case event := <-subscription.Events():
+			context.SSEvent(event.Kind, event)
+			return true
+		}
+	})
+
+	logger.Info("Event stream client '%s' disconnected, %d events dropped", context.ClientIP(), subscription.Dropped())
+}
@@ -0,0 +1,14 @@
+package rest
+
+import (
+	"github.com/FatProteins/master-thesis-code/liveness"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// LivenessApi exposes the liveness of the nodes sending heartbeats.
+func LivenessApi(router gin.IRouter, monitor *liveness.Monitor) {
+	router.GET("/liveness", func(context *gin.Context) {
+		context.JSON(http.StatusOK, monitor.Status())
+	})
+}
@@ -0,0 +1,27 @@
+package rest
+
+import (
+	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// LogApi reads and changes the log levels at runtime.
+func LogApi(router gin.IRouter) {
+	router.GET("/log", func(context *gin.Context) {
+		context.JSON(http.StatusOK, daLogger.CurrentLevels())
+	})
+	router.PUT("/log", func(context *gin.Context) {
+		var levels daLogger.Levels
+		if err := context.BindJSON(&levels); err != nil {
+			logger.ErrorErr(err, "Could not read log levels entity")
+			return
+		}
+		if err := daLogger.Configure(levels); err != nil {
+			context.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		logger.Info("Changed log levels to %s, package overrides %v", levels.Level, levels.Packages)
+		context.JSON(http.StatusOK, daLogger.CurrentLevels())
+	})
+}
@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+const shutdownTimeout = 5 * time.Second
+
+type Server struct {
+	router     *gin.Engine
+	httpServer *http.Server
+}
+
+func NewServer(address string) *Server {
+	gin.SetMode(gin.ReleaseMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	ConfigApi(router)
+
+	return &Server{router: router, httpServer: &http.Server{Addr: address, Handler: router}}
+}
+
+func (server *Server) Router() gin.IRouter {
+	return server.router
+}
+
+func (server *Server) RunAsync(ctx context.Context) {
+	go func() {
+		err := server.httpServer.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.ErrorErr(err, "HTTP server on '%s' failed", server.httpServer.Addr)
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		_ = server.httpServer.Shutdown(shutdownCtx)
+	}()
+}
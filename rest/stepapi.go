@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"errors"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/operator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type stepDecision struct {
+	Action   string `json:"action" binding:"required"`
+	Duration string `json:"duration"`
+}
+
+// StepApi lets an operator list the messages held in step mode and choose their actions.
+func StepApi(router gin.IRouter, stepper *operator.Stepper) {
+	router.GET("/step/pending", func(context *gin.Context) {
+		context.JSON(http.StatusOK, stepper.Pending())
+	})
+	router.POST("/step/pending/:id", func(context *gin.Context) {
+		chooseAction(context, stepper)
+	})
+}
+
+func chooseAction(context *gin.Context, stepper *operator.Stepper) {
+	id, ok := parseId(context)
+	if !ok {
+		return
+	}
+
+	var decision stepDecision
+	if err := context.BindJSON(&decision); err != nil {
+		logger.ErrorErr(err, "Could not read step decision entity")
+		return
+	}
+
+	actionType, ok := ParseActionType(decision.Action)
+	if !ok {
+		context.String(http.StatusBadRequest, "unknown action '%s'", decision.Action)
+		return
+	}
+
+	duration := time.Duration(-1)
+	if len(decision.Duration) != 0 {
+		var err error
+		duration, err = time.ParseDuration(decision.Duration)
+		if err != nil || duration < 0 {
+			context.String(http.StatusBadRequest, "invalid duration '%s'", decision.Duration)
+			return
+		}
+	}
+
+	err := stepper.Choose(id, actionType, duration)
+	if errors.Is(err, operator.ErrUnknownMessage) {
+		context.String(http.StatusNotFound, "no pending message with id %d", id)
+		return
+	}
+
+	context.Status(http.StatusNoContent)
+}
+
+// ParseActionType accepts the enum names of protocol.ActionType with or without the '_ACTION_TYPE' suffix.
+func ParseActionType(name string) (protocol.ActionType, bool) {
+	name = strings.ToUpper(name)
+	value, ok := protocol.ActionType_value[name]
+	if !ok {
+		value, ok = protocol.ActionType_value[name+"_ACTION_TYPE"]
+	}
+	return protocol.ActionType(value), ok
+}
@@ -2,13 +2,23 @@ package main
 
 import (
 	"context"
+	"github.com/FatProteins/master-thesis-code/events"
+	"github.com/FatProteins/master-thesis-code/faultlog"
+	"github.com/FatProteins/master-thesis-code/liveness"
 	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/metrics"
 	"github.com/FatProteins/master-thesis-code/network"
+	"github.com/FatProteins/master-thesis-code/operator"
+	"github.com/FatProteins/master-thesis-code/plugin"
 	"github.com/FatProteins/master-thesis-code/process"
+	"github.com/FatProteins/master-thesis-code/replay"
+	"github.com/FatProteins/master-thesis-code/rest"
 	"github.com/FatProteins/master-thesis-code/setup"
-	"net"
+	"github.com/FatProteins/master-thesis-code/tracing"
 	"os"
 	"os/signal"
+	"strings"
+	"time"
 )
 
 var logger = daLogger.NewLogger("main")
@@ -19,6 +29,8 @@ func Run() {
 		logger.ErrorErr(err, "Could not read fault config yaml file")
 		os.Exit(1)
 	}
+	_ = daLogger.SetFormat(faultConfig.Log.Format)
+	_ = daLogger.Configure(daLogger.Levels{Level: faultConfig.Log.Level, Packages: faultConfig.Log.Packages})
 	configString, err := faultConfig.String()
 	if err != nil {
 		logger.ErrorErr(err, "Failed to serialize config file '%s' to yaml", ConfigPath)
@@ -26,30 +38,129 @@ func Run() {
 	}
 	logger.Info("Using fault config:\n%s", configString)
 
-	localAddr, err := net.ResolveUnixAddr("unixgram", faultConfig.UnixToDaDomainSocketPath)
-	if err != nil {
-		logger.ErrorErr(err, "Could not resolve unix address")
-		os.Exit(1)
+	if len(faultConfig.Tracing.Exporter) != 0 {
+		shutdownTracing, err := tracing.Setup(context.Background(), faultConfig.Tracing.Exporter, faultConfig.Tracing.Endpoint, faultConfig.Tracing.FilePath)
+		if err != nil {
+			logger.ErrorErr(err, "Could not set up '%s' trace exporter", faultConfig.Tracing.Exporter)
+			os.Exit(1)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := shutdownTracing(ctx)
+			if err != nil {
+				logger.ErrorErr(err, "Failed to flush traces")
+			}
+		}()
+		logger.Info("Exporting traces with '%s' exporter", faultConfig.Tracing.Exporter)
+	}
+
+	var transport network.Transport
+	switch faultConfig.Transport {
+	case network.TransportUnix:
+		transport, err = network.NewUnixTransport(faultConfig.SocketType, faultConfig.UnixToDaDomainSocketPath)
+		if err != nil {
+			logger.ErrorErr(err, "Could not create unix transport")
+			os.Exit(1)
+		}
+	case network.TransportTcp:
+		transport = network.NewTcpTransport(faultConfig.ListenAddress)
+	case network.TransportGrpc:
+		transport = network.NewGrpcTransport(faultConfig.ListenAddress, faultConfig.MaxMessageSize)
 	}
 
 	msgChan := make(chan network.Message, 10000)
 	respChan := make(chan network.Message, 10000)
-	networkLayer, err := network.NewNetworkLayer(msgChan, respChan, localAddr, faultConfig.UnixToDaDomainSocketPath)
+	networkLayer, err := network.NewNetworkLayer(msgChan, respChan, transport, faultConfig.MaxMessageSize)
 	if err != nil {
 		logger.ErrorErr(err, "Could not create network layer")
 		os.Exit(1)
 	}
-	logger.Info("Listening to unix socket on '%s'", localAddr.String())
+	logger.Info("Listening on '%s'", transport.Address())
 
-	actionPicker := setup.NewActionPicker(faultConfig)
-	processor := process.NewProcessor(msgChan, respChan, actionPicker)
+	var faultLog *faultlog.Writer
+	if len(faultConfig.FaultLogPath) != 0 {
+		faultLog, err = faultlog.NewWriter(faultConfig.FaultLogPath)
+		if err != nil {
+			logger.ErrorErr(err, "Could not open fault log '%s'", faultConfig.FaultLogPath)
+			os.Exit(1)
+		}
+		defer faultLog.Close()
+		logger.Info("Writing fault events to '%s'", faultConfig.FaultLogPath)
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	actionPicker := setup.NewActionPicker(faultConfig)
+	var actionDecider setup.ActionDecider = actionPicker
+	var stepper *operator.Stepper
+	if faultConfig.StepMode.Enabled {
+		stepper = operator.NewStepper(ctx, actionPicker, time.Duration(faultConfig.StepMode.Timeout)*time.Millisecond)
+		actionDecider = stepper
+		logger.Info("Step mode enabled, waiting up to %dms for an operator decision per message", faultConfig.StepMode.Timeout)
+	}
+	if len(faultConfig.Trace.RecordPath) != 0 {
+		recorder, err := replay.NewRecorder(actionDecider, faultConfig.Trace.RecordPath)
+		if err != nil {
+			logger.ErrorErr(err, "Could not create trace file '%s'", faultConfig.Trace.RecordPath)
+			os.Exit(1)
+		}
+		defer recorder.Close()
+		actionDecider = recorder
+		logger.Info("Recording decisions to '%s'", faultConfig.Trace.RecordPath)
+	} else if len(faultConfig.Trace.ReplayPath) != 0 {
+		replayer, err := replay.NewReplayer(actionDecider, faultConfig.Trace.ReplayPath)
+		if err != nil {
+			logger.ErrorErr(err, "Could not read trace file '%s'", faultConfig.Trace.ReplayPath)
+			os.Exit(1)
+		}
+		defer func() {
+			logger.Info("Replay finished with %d divergences and %d remaining decisions", replayer.Divergences(), replayer.Remaining())
+		}()
+		actionDecider = replayer
+		logger.Info("Replaying decisions from '%s'", faultConfig.Trace.ReplayPath)
+	}
+
+	eventHub := events.NewHub()
+	breakpoints := operator.NewBreakpoints()
+	var livenessMonitor *liveness.Monitor
+	if faultConfig.Heartbeat.Timeout != 0 {
+		livenessMonitor = liveness.NewMonitor(time.Duration(faultConfig.Heartbeat.Timeout)*time.Millisecond, eventHub)
+		networkLayer.OnHeartbeat(livenessMonitor.Heartbeat)
+		logger.Info("Expecting heartbeats at least every %dms", faultConfig.Heartbeat.Timeout)
+	}
+	processor := process.NewProcessor(msgChan, respChan, actionDecider, faultLog, eventHub, breakpoints, plugin.Default(), livenessMonitor)
+	metrics.RegisterQueueLength("messages", processor.Backlog)
+	if faultConfig.Overhead.NoopBudget != 0 {
+		processor.SetNoopBudget(time.Duration(faultConfig.Overhead.NoopBudget) * time.Millisecond)
+		logger.Info("Warning when handling noop takes longer than %dms", faultConfig.Overhead.NoopBudget)
+	}
+	if faultConfig.Debug.PoisonFreedMessages {
+		network.SetPoisonFreedMessages(true)
+		logger.Info("Poisoning freed messages, do not use this run for measurements")
+	}
+	if names := plugin.Default().Names(); len(names) != 0 {
+		logger.Info("Using protocol plugins %s", strings.Join(names, ", "))
+	}
+
 	logger.Info("Starting application...")
+	if len(faultConfig.ApiAddress) != 0 {
+		server := rest.NewServer(faultConfig.ApiAddress)
+		rest.EventsApi(server.Router(), eventHub)
+		rest.BreakpointApi(server.Router(), breakpoints)
+		rest.ConnectionsApi(server.Router(), networkLayer)
+		rest.LivenessApi(server.Router(), livenessMonitor)
+		rest.LogApi(server.Router())
+		if stepper != nil {
+			rest.StepApi(server.Router(), stepper)
+		}
+		server.RunAsync(ctx)
+		logger.Info("Serving API on '%s'", faultConfig.ApiAddress)
+	}
 	networkLayer.RunAsync(ctx)
 	processor.RunAsync(ctx)
+	livenessMonitor.RunAsync(ctx)
 
 	logger.Info("Ready.")
 	interrupt := make(chan os.Signal, 1)
@@ -1,28 +1,48 @@
 package setup
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/constants"
 	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/metrics"
 	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/stat/distuv"
 	"google.golang.org/protobuf/types/known/anypb"
 	"gopkg.in/yaml.v3"
+	"math/rand"
 	"os"
 	"os/exec"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 var logger = daLogger.NewLogger("setup")
 
 type FaultConfig struct {
+	Transport                  string `yaml:"transport"`
+	ListenAddress              string `yaml:"listen-address"`
 	UnixToDaDomainSocketPath   string `yaml:"unix-to-da-domain-socket-path"`
 	UnixFromDaDomainSocketPath string `yaml:"unix-from-da-domain-socket-path"`
+	SocketType                 string `yaml:"socket-type"`
+	MaxMessageSize             int    `yaml:"max-message-size"`
 	FaultsEnabled              bool   `yaml:"faults-enabled"`
+	FaultLogPath               string `yaml:"fault-log-path"`
+	ApiAddress                 string `yaml:"api-address"`
 	Actions                    struct {
-		Noop struct {
+		// RandomDurations samples the duration of every action uniformly up to its max duration instead of using
+		// the max duration, from a source seeded with Seed, or the time if Seed is 0.
+		RandomDurations bool  `yaml:"random-durations"`
+		Seed            int64 `yaml:"seed"`
+		Noop            struct {
 			Probability float64 `yaml:"probability"`
 		} `yaml:"noop"`
 		Halt struct {
@@ -46,6 +66,33 @@ type FaultConfig struct {
 			MaxDuration int     `yaml:"max-duration"`
 		} `yaml:"resend-last-message"`
 	} `yaml:"actions"`
+	Trace struct {
+		RecordPath string `yaml:"record-path"`
+		ReplayPath string `yaml:"replay-path"`
+	} `yaml:"trace"`
+	StepMode struct {
+		Enabled bool `yaml:"enabled"`
+		Timeout int  `yaml:"timeout"`
+	} `yaml:"step-mode"`
+	Heartbeat struct {
+		Timeout int `yaml:"timeout"`
+	} `yaml:"heartbeat"`
+	Overhead struct {
+		NoopBudget int `yaml:"noop-budget"`
+	} `yaml:"overhead"`
+	Debug struct {
+		PoisonFreedMessages bool `yaml:"poison-freed-messages"`
+	} `yaml:"debug"`
+	Tracing struct {
+		Exporter string `yaml:"exporter"`
+		Endpoint string `yaml:"endpoint"`
+		FilePath string `yaml:"file-path"`
+	} `yaml:"tracing"`
+	Log struct {
+		Level    string            `yaml:"level"`
+		Format   string            `yaml:"format"`
+		Packages map[string]string `yaml:"packages"`
+	} `yaml:"log"`
 }
 
 const (
@@ -57,11 +104,25 @@ const (
 )
 
 type FaultAction interface {
-	Perform(resetConnFunc func())
+	// Perform applies the action to the node that reported the message. It is called concurrently for messages of
+	// different connections.
+	Perform(ctx context.Context, node uint32, duration time.Duration, resetConnFunc func())
 	Name() string
 	GenerateResponse(*protocol.Message) error
 }
 
+// Decision is the action chosen for a message together with its sampled parameters.
+type Decision struct {
+	ActionType protocol.ActionType
+	Duration   time.Duration
+}
+
+// ActionDecider decides which action to perform for an incoming message.
+type ActionDecider interface {
+	Decide(message *protocol.Message) Decision
+	GetAction(actionType protocol.ActionType) FaultAction
+}
+
 func ReadFaultConfig(path string) (FaultConfig, error) {
 	var config FaultConfig
 	content, err := os.ReadFile(path)
@@ -74,6 +135,22 @@ func ReadFaultConfig(path string) (FaultConfig, error) {
 		return config, err
 	}
 
+	if len(config.Transport) == 0 {
+		config.Transport = "unix"
+	}
+	if len(config.SocketType) == 0 {
+		config.SocketType = "unix"
+	}
+	if config.MaxMessageSize == 0 {
+		config.MaxMessageSize = constants.DefaultMaxMessageSize
+	}
+	if len(config.Log.Level) == 0 {
+		config.Log.Level = "info"
+	}
+	if len(config.Log.Format) == 0 {
+		config.Log.Format = daLogger.FormatText
+	}
+
 	err = config.verifyConfig()
 	if err != nil {
 		return config, err
@@ -84,12 +161,29 @@ func ReadFaultConfig(path string) (FaultConfig, error) {
 
 func (config *FaultConfig) verifyConfig() error {
 	baseErr := errors.New("config error")
-	if len(config.UnixToDaDomainSocketPath) == 0 {
-		return errors.Join(baseErr, errors.New("unix to DA domain socket path is empty"))
+	switch config.Transport {
+	case "unix":
+		if len(config.UnixToDaDomainSocketPath) == 0 {
+			return errors.Join(baseErr, errors.New("unix to DA domain socket path is empty"))
+		}
+
+		if len(config.UnixFromDaDomainSocketPath) == 0 {
+			return errors.Join(baseErr, errors.New("unix from DA domain socket path is empty"))
+		}
+	case "tcp", "grpc":
+		if len(config.ListenAddress) == 0 {
+			return errors.Join(baseErr, fmt.Errorf("listen address is required for transport '%s'", config.Transport))
+		}
+	default:
+		return errors.Join(baseErr, errors.New("transport must be 'unix', 'tcp' or 'grpc'"))
 	}
 
-	if len(config.UnixFromDaDomainSocketPath) == 0 {
-		return errors.Join(baseErr, errors.New("unix from DA domain socket path is empty"))
+	if config.SocketType != "unix" && config.SocketType != "unixpacket" {
+		return errors.Join(baseErr, errors.New("socket type must be 'unix' or 'unixpacket'"))
+	}
+
+	if config.MaxMessageSize < constants.MaxMessageSize() {
+		return errors.Join(baseErr, fmt.Errorf("max message size must be at least %d bytes", constants.MaxMessageSize()))
 	}
 
 	if len(config.Actions.Pause.PauseCommand) == 0 {
@@ -108,6 +202,54 @@ func (config *FaultConfig) verifyConfig() error {
 		return errors.Join(baseErr, errors.New("restart command is empty"))
 	}
 
+	if len(config.Trace.RecordPath) != 0 && len(config.Trace.ReplayPath) != 0 {
+		return errors.Join(baseErr, errors.New("trace record path and replay path are mutually exclusive"))
+	}
+
+	if config.StepMode.Enabled && len(config.ApiAddress) == 0 {
+		return errors.Join(baseErr, errors.New("step mode requires an api address"))
+	}
+
+	if config.StepMode.Enabled && config.StepMode.Timeout <= 0 {
+		return errors.Join(baseErr, errors.New("step mode requires a positive timeout, otherwise every message falls back to noop at once"))
+	}
+
+	if config.Heartbeat.Timeout < 0 {
+		return errors.Join(baseErr, errors.New("heartbeat timeout must not be negative"))
+	}
+
+	if config.Overhead.NoopBudget < 0 {
+		return errors.Join(baseErr, errors.New("noop budget must not be negative"))
+	}
+
+	switch config.Tracing.Exporter {
+	case "":
+	case tracing.ExporterOtlp:
+		if len(config.Tracing.Endpoint) == 0 {
+			return errors.Join(baseErr, errors.New("otlp trace exporter requires an endpoint"))
+		}
+	case tracing.ExporterFile:
+		if len(config.Tracing.FilePath) == 0 {
+			return errors.Join(baseErr, errors.New("file trace exporter requires a file path"))
+		}
+	default:
+		return errors.Join(baseErr, errors.New("trace exporter must be 'otlp' or 'file'"))
+	}
+
+	if config.Log.Format != daLogger.FormatText && config.Log.Format != daLogger.FormatJson {
+		return errors.Join(baseErr, errors.New("log format must be 'text' or 'json'"))
+	}
+
+	if _, err := daLogger.ParseLevel(config.Log.Level); err != nil {
+		return errors.Join(baseErr, err)
+	}
+
+	for prefix, level := range config.Log.Packages {
+		if _, err := daLogger.ParseLevel(level); err != nil {
+			return errors.Join(baseErr, fmt.Errorf("log level of package '%s': %w", prefix, err))
+		}
+	}
+
 	return nil
 }
 
@@ -123,6 +265,10 @@ func (config *FaultConfig) String() (string, error) {
 type ActionPicker struct {
 	cumProbabilities []float64
 	actions          map[protocol.ActionType]FaultAction
+	durations        map[protocol.ActionType]time.Duration
+	// random samples the durations if they are randomized, it is guarded by the random mutex.
+	randomMutex sync.Mutex
+	random      *rand.Rand
 }
 
 func NewActionPicker(config FaultConfig) *ActionPicker {
@@ -147,7 +293,22 @@ func NewActionPicker(config FaultConfig) *ActionPicker {
 		protocol.ActionType_STOP_ACTION_TYPE:                &StopAction{config, stopCmd, stopArgs, restartCmd, restartArgs},
 		protocol.ActionType_RESEND_LAST_MESSAGE_ACTION_TYPE: &ResendLastMessageAction{},
 	}
-	return &ActionPicker{cumProbabilities: cumSum, actions: actions}
+	durations := map[protocol.ActionType]time.Duration{
+		protocol.ActionType_HALT_ACTION_TYPE:                time.Duration(config.Actions.Halt.MaxDuration) * time.Millisecond,
+		protocol.ActionType_PAUSE_ACTION_TYPE:               time.Duration(config.Actions.Pause.MaxDuration) * time.Millisecond,
+		protocol.ActionType_STOP_ACTION_TYPE:                time.Duration(config.Actions.Stop.MaxDuration) * time.Millisecond,
+		protocol.ActionType_RESEND_LAST_MESSAGE_ACTION_TYPE: time.Duration(config.Actions.ResendLastMessage.MaxDuration) * time.Millisecond,
+	}
+	actionPicker := &ActionPicker{cumProbabilities: cumSum, actions: actions, durations: durations}
+	if config.Actions.RandomDurations {
+		seed := config.Actions.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		logger.Info("Sampling action durations with seed %d", seed)
+		actionPicker.random = rand.New(rand.NewSource(seed))
+	}
+	return actionPicker
 }
 
 func (actionPicker *ActionPicker) DetermineAction() FaultAction {
@@ -158,6 +319,23 @@ func (actionPicker *ActionPicker) DetermineAction() FaultAction {
 	return action
 }
 
+// Decide uses the action requested by the node. Its duration is the configured max duration, or sampled up to it
+// with random durations.
+func (actionPicker *ActionPicker) Decide(message *protocol.Message) Decision {
+	duration := actionPicker.Duration(message.ActionType)
+	if actionPicker.random != nil && duration > 0 {
+		actionPicker.randomMutex.Lock()
+		duration = time.Duration(actionPicker.random.Int63n(int64(duration) + 1))
+		actionPicker.randomMutex.Unlock()
+	}
+	return Decision{ActionType: message.ActionType, Duration: duration}
+}
+
+// Duration returns the configured duration of an action.
+func (actionPicker *ActionPicker) Duration(actionType protocol.ActionType) time.Duration {
+	return actionPicker.durations[actionType]
+}
+
 func (actionPicker *ActionPicker) GetAction(actionType protocol.ActionType) FaultAction {
 	return actionPicker.actions[actionType]
 }
@@ -165,19 +343,27 @@ func (actionPicker *ActionPicker) GetAction(actionType protocol.ActionType) Faul
 type NoopAction struct {
 }
 
-func (action *NoopAction) GenerateResponse(response *protocol.Message) error {
+// responseObject is the payload of every response: an empty DA response, which nodes expect as payload. It is
+// computed once instead of per response and shared by all responses, so it must not be modified.
+var responseObject = func() *anypb.Any {
+	messageObject := &anypb.Any{}
+	_ = messageObject.MarshalFrom(&protocol.Message{MessageType: protocol.MessageType_DA_RESPONSE, MessageObject: &anypb.Any{}})
+	return messageObject
+}()
+
+// generateResponse resets the response to a DA response. The processor sets its action type.
+func generateResponse(response *protocol.Message) error {
 	response.Reset()
 	response.MessageType = protocol.MessageType_DA_RESPONSE
-	response.MessageObject = &anypb.Any{}
-	err := response.MessageObject.MarshalFrom(response)
-	if err != nil {
-		return err
-	}
-
+	response.MessageObject = responseObject
 	return nil
 }
 
-func (action *NoopAction) Perform(func()) {
+func (action *NoopAction) GenerateResponse(response *protocol.Message) error {
+	return generateResponse(response)
+}
+
+func (action *NoopAction) Perform(context.Context, uint32, time.Duration, func()) {
 	// Do nothing
 }
 
@@ -190,24 +376,15 @@ type HaltAction struct {
 }
 
 func (action *HaltAction) GenerateResponse(response *protocol.Message) error {
-	response.Reset()
-	response.MessageType = protocol.MessageType_DA_RESPONSE
-	response.MessageObject = &anypb.Any{}
-	err := response.MessageObject.MarshalFrom(response)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return generateResponse(response)
 }
 
 func (action *HaltAction) Name() string {
 	return "Halt"
 }
 
-func (action *HaltAction) Perform(func()) {
-	// TODO: Introduce randomness
-	time.Sleep(time.Duration(action.config.Actions.Halt.MaxDuration) * time.Millisecond)
+func (action *HaltAction) Perform(_ context.Context, _ uint32, duration time.Duration, _ func()) {
+	time.Sleep(duration)
 }
 
 type PauseAction struct {
@@ -221,33 +398,26 @@ type PauseAction struct {
 }
 
 func (action *PauseAction) GenerateResponse(response *protocol.Message) error {
-	response.Reset()
-	response.MessageType = protocol.MessageType_DA_RESPONSE
-	response.MessageObject = &anypb.Any{}
-	err := response.MessageObject.MarshalFrom(response)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return generateResponse(response)
 }
 
 func (action *PauseAction) Name() string {
 	return "Pause"
 }
 
-func (action *PauseAction) Perform(func()) {
-	pauseConfig := action.config.Actions.Pause
-	err := exec.Command(action.pauseCmd, action.pauseArgs...).Run()
+func (action *PauseAction) Perform(ctx context.Context, node uint32, duration time.Duration, _ func()) {
+	err := runCommand(ctx, "pause", node, action.pauseCmd, action.pauseArgs)
 	if err != nil {
 		logger.ErrorErr(err, "Failed to execute pause command")
+		metrics.FaultCommandFailures.WithLabelValues("pause").Inc()
 		return
 	}
 
-	time.Sleep(time.Duration(pauseConfig.MaxDuration) * time.Millisecond)
-	err = exec.Command(action.continueCmd, action.continueArgs...).Run()
+	time.Sleep(duration)
+	err = runCommand(ctx, "continue", node, action.continueCmd, action.continueArgs)
 	if err != nil {
 		logger.ErrorErr(err, "Failed to execute continue command")
+		metrics.FaultCommandFailures.WithLabelValues("continue").Inc()
 		return
 	}
 }
@@ -263,27 +433,19 @@ type StopAction struct {
 }
 
 func (action *StopAction) GenerateResponse(response *protocol.Message) error {
-	response.Reset()
-	response.MessageType = protocol.MessageType_DA_RESPONSE
-	response.MessageObject = &anypb.Any{}
-	err := response.MessageObject.MarshalFrom(response)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return generateResponse(response)
 }
 
 func (action *StopAction) Name() string {
 	return "Stop"
 }
 
-func (action *StopAction) Perform(resetConnFunc func()) {
-	stopConfig := &action.config.Actions.Stop
-	logger.Info("Stopping container with command %s", action.stopCmd)
-	err := exec.Command(action.stopCmd, action.stopArgs...).Run()
+func (action *StopAction) Perform(ctx context.Context, node uint32, duration time.Duration, resetConnFunc func()) {
+	logger.Info("Stopping container of node %d with command %s", node, action.stopCmd)
+	err := runCommand(ctx, "stop", node, action.stopCmd, action.stopArgs)
 	if err != nil {
 		logger.ErrorErr(err, "Failed to execute stop command")
+		metrics.FaultCommandFailures.WithLabelValues("stop").Inc()
 		return
 	}
 
@@ -291,12 +453,13 @@ func (action *StopAction) Perform(resetConnFunc func()) {
 	resetConnFunc()
 
 	logger.Info("Waiting after stop...")
-	time.Sleep(time.Duration(stopConfig.MaxDuration) * time.Millisecond)
-	logger.Info("Restarting container with command %s", action.restartCmd)
+	time.Sleep(duration)
+	logger.Info("Restarting container of node %d with command %s", node, action.restartCmd)
 	logger.Info("Restarting container with args %s", action.restartArgs)
-	err = exec.Command(action.restartCmd, action.restartArgs...).Run()
+	err = runCommand(ctx, "restart", node, action.restartCmd, action.restartArgs)
 	if err != nil {
 		logger.ErrorErr(err, "Failed to execute restart command")
+		metrics.FaultCommandFailures.WithLabelValues("restart").Inc()
 		return
 	}
 
@@ -307,25 +470,40 @@ type ResendLastMessageAction struct {
 }
 
 func (action *ResendLastMessageAction) GenerateResponse(response *protocol.Message) error {
-	response.Reset()
-	response.MessageType = protocol.MessageType_DA_RESPONSE
-	response.MessageObject = &anypb.Any{}
-	err := response.MessageObject.MarshalFrom(response)
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return generateResponse(response)
 }
 
 func (action *ResendLastMessageAction) Name() string {
 	return "ResendLastMessage"
 }
 
-func (action *ResendLastMessageAction) Perform(func()) {
+func (action *ResendLastMessageAction) Perform(context.Context, uint32, time.Duration, func()) {
 
 }
 
+// nodePlaceholder in a fault command is replaced by the ID of the node the fault is performed for, e.g.
+// 'docker pause etcd{node}', so a DA serving several nodes pauses the right container.
+const nodePlaceholder = "{node}"
+
+// runCommand runs an external fault command for the node in a span of its own.
+func runCommand(ctx context.Context, name string, node uint32, cmd string, args []string) error {
+	nodeId := strconv.FormatUint(uint64(node), 10)
+	cmd = strings.ReplaceAll(cmd, nodePlaceholder, nodeId)
+	args = append([]string(nil), args...)
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(arg, nodePlaceholder, nodeId)
+	}
+
+	_, span := tracing.Tracer.Start(ctx, name+" command", trace.WithAttributes(attribute.StringSlice("da.command", append([]string{cmd}, args...))))
+	defer span.End()
+
+	err := exec.Command(cmd, args...).Run()
+	if err != nil {
+		tracing.Fail(span, err)
+	}
+	return err
+}
+
 func splitCommand(command string) (string, []string) {
 	cmdSplit := strings.Split(command, " ")
 	cmd := cmdSplit[0]
@@ -0,0 +1,64 @@
+package setup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/FatProteins/master-thesis-code/constants"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+)
+
+func TestPauseRunsCommandsOfNode(t *testing.T) {
+	dir := t.TempDir()
+	config := FaultConfig{}
+	config.Actions.Pause.PauseCommand = "touch " + filepath.Join(dir, "paused-{node}")
+	config.Actions.Pause.ContinueCommand = "touch " + filepath.Join(dir, "continued-{node}")
+	picker := NewActionPicker(config)
+
+	picker.GetAction(protocol.ActionType_PAUSE_ACTION_TYPE).Perform(context.Background(), 3, 0, nil)
+
+	for _, name := range []string{"paused-3", "continued-3"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("command for node 3 did not run: %v", err)
+		}
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*{node}")); len(matches) != 0 {
+		t.Errorf("placeholder was not replaced: %v", matches)
+	}
+}
+
+// validConfig returns a config passing verifyConfig.
+func validConfig() FaultConfig {
+	config := FaultConfig{
+		Transport:                  "unix",
+		UnixToDaDomainSocketPath:   "/tmp/to-da.sock",
+		UnixFromDaDomainSocketPath: "/tmp/from-da.sock",
+		SocketType:                 "unix",
+		MaxMessageSize:             constants.DefaultMaxMessageSize,
+		ApiAddress:                 ":8080",
+	}
+	config.Actions.Pause.PauseCommand = "true"
+	config.Actions.Pause.ContinueCommand = "true"
+	config.Actions.Stop.StopCommand = "true"
+	config.Actions.Stop.RestartCommand = "true"
+	config.Log.Level = "info"
+	config.Log.Format = "text"
+	return config
+}
+
+func TestVerifyConfigStepModeTimeout(t *testing.T) {
+	for _, test := range []struct {
+		timeout int
+		valid   bool
+	}{{-1, false}, {0, false}, {1, true}, {30000, true}} {
+		config := validConfig()
+		config.StepMode.Enabled = true
+		config.StepMode.Timeout = test.timeout
+		err := config.verifyConfig()
+		if (err == nil) != test.valid {
+			t.Errorf("step mode timeout %d: got %v, want valid %t", test.timeout, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+package simulator
+
+import (
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/util"
+	"google.golang.org/protobuf/proto"
+	"time"
+)
+
+type raftState int
+
+const (
+	stateFollower raftState = iota
+	stateCandidate
+	stateLeader
+)
+
+var raftStateNames = []string{"follower", "candidate", "leader"}
+
+func (state raftState) String() string {
+	return raftStateNames[state]
+}
+
+type entry struct {
+	term uint64
+	data []byte
+}
+
+type rpcKind int
+
+const (
+	rpcVoteRequest rpcKind = iota
+	rpcVoteResponse
+	rpcAppendEntries
+	rpcAppendResponse
+)
+
+// rpc is a Raft message between simulated nodes.
+type rpc struct {
+	kind         rpcKind
+	from         uint32
+	to           uint32
+	term         uint64
+	lastLogIndex uint64
+	lastLogTerm  uint64
+	success      bool
+	prevLogIndex uint64
+	prevLogTerm  uint64
+	entries      []entry
+	leaderCommit uint64
+	matchIndex   uint64
+}
+
+// node is a simplified Raft node: leader election and log replication without snapshots or membership changes.
+type node struct {
+	id          uint32
+	simulator   *Simulator
+	state       raftState
+	term        uint64
+	votedFor    uint32
+	log         []entry
+	commitIndex uint64
+	leader      uint32
+	votes       map[uint32]bool
+	nextIndex   map[uint32]uint64
+	matchIndex  map[uint32]uint64
+	// timer invalidates scheduled timeouts when it is incremented.
+	timer       uint64
+	crashed     bool
+	pausedUntil time.Duration
+	restarts    int
+}
+
+// whenRunning runs the function unless the node is crashed. While the node is halted or paused, the function
+// is deferred until the node resumes.
+func (node *node) whenRunning(run func()) {
+	if node.crashed {
+		return
+	}
+	if now := node.simulator.now; now < node.pausedUntil {
+		node.simulator.schedule(node.pausedUntil-now, func() { node.whenRunning(run) })
+		return
+	}
+
+	run()
+}
+
+// applyVerdict performs the action of the DA on the node, handle is the work the node does after the response.
+func (node *node) applyVerdict(verdict Verdict, handle func()) {
+	switch verdict.ActionType {
+	case protocol.ActionType_HALT_ACTION_TYPE, protocol.ActionType_PAUSE_ACTION_TYPE:
+		node.pausedUntil = node.simulator.now + verdict.Duration
+		node.whenRunning(handle)
+	case protocol.ActionType_STOP_ACTION_TYPE:
+		node.crash(verdict.Duration)
+	case protocol.ActionType_RESEND_LAST_MESSAGE_ACTION_TYPE:
+		handle()
+		node.whenRunning(handle)
+	default:
+		handle()
+	}
+}
+
+func (node *node) report(messageType protocol.MessageType, event proto.Message, handle func()) {
+	node.applyVerdict(node.simulator.report(node, messageType, event), handle)
+}
+
+// crash loses all volatile state, the node restarts after the given duration.
+func (node *node) crash(duration time.Duration) {
+	node.crashed = true
+	node.timer++
+	node.restarts++
+	node.state = stateFollower
+	node.leader = 0
+	node.votes = nil
+	node.simulator.schedule(duration, func() {
+		node.crashed = false
+		node.pausedUntil = 0
+		node.resetElectionTimer()
+	})
+}
+
+func (node *node) resetElectionTimer() {
+	node.timer++
+	timer := node.timer
+	config := node.simulator.config
+	node.simulator.schedule(node.simulator.randomDuration(config.MinElectionTimeout, config.MaxElectionTimeout), func() {
+		if timer == node.timer {
+			node.whenRunning(node.electionTimeout)
+		}
+	})
+}
+
+func (node *node) scheduleHeartbeat() {
+	timer := node.timer
+	node.simulator.schedule(node.simulator.config.HeartbeatInterval, func() {
+		if timer == node.timer {
+			node.whenRunning(node.heartbeat)
+		}
+	})
+}
+
+func (node *node) electionTimeout() {
+	if node.state == stateLeader {
+		return
+	}
+
+	if node.leader != 0 {
+		node.report(protocol.MessageType_LEADER_SUSPECTED, &protocol.LeaderSuspected{LeaderId: node.leader, SuspectingNodeId: node.id}, node.startElection)
+		return
+	}
+	node.startElection()
+}
+
+func (node *node) startElection() {
+	node.term++
+	node.state = stateCandidate
+	node.votedFor = node.id
+	node.leader = 0
+	node.votes = map[uint32]bool{node.id: true}
+	node.resetElectionTimer()
+	if node.hasMajority(len(node.votes)) {
+		node.becomeLeader()
+		return
+	}
+
+	lastLogIndex, lastLogTerm := node.lastLog()
+	for _, id := range node.simulator.nodeIds {
+		if id != node.id {
+			node.send(rpc{kind: rpcVoteRequest, to: id, lastLogIndex: lastLogIndex, lastLogTerm: lastLogTerm})
+		}
+	}
+}
+
+func (node *node) becomeLeader() {
+	node.state = stateLeader
+	node.leader = node.id
+	node.timer++
+	node.nextIndex = make(map[uint32]uint64)
+	node.matchIndex = make(map[uint32]uint64)
+	for _, id := range node.simulator.nodeIds {
+		node.nextIndex[id] = uint64(len(node.log)) + 1
+	}
+
+	// An entry of the new term lets the leader commit the entries of previous terms.
+	node.log = append(node.log, entry{term: node.term})
+	node.advanceLeaderCommit()
+	node.heartbeat()
+}
+
+func (node *node) becomeFollower(term uint64) {
+	node.term = term
+	node.state = stateFollower
+	node.votedFor = 0
+	node.leader = 0
+	node.resetElectionTimer()
+}
+
+func (node *node) heartbeat() {
+	if node.state != stateLeader {
+		return
+	}
+
+	for _, id := range node.simulator.nodeIds {
+		if id != node.id {
+			node.sendAppend(id)
+		}
+	}
+	node.scheduleHeartbeat()
+}
+
+func (node *node) propose(data []byte) {
+	node.log = append(node.log, entry{term: node.term, data: data})
+	node.advanceLeaderCommit()
+	for _, id := range node.simulator.nodeIds {
+		if id != node.id {
+			node.sendAppend(id)
+		}
+	}
+}
+
+func (node *node) sendAppend(to uint32) {
+	prevLogIndex := node.nextIndex[to] - 1
+	var prevLogTerm uint64
+	if prevLogIndex > 0 {
+		prevLogTerm = node.log[prevLogIndex-1].term
+	}
+
+	entries := append([]entry(nil), node.log[prevLogIndex:]...)
+	node.send(rpc{kind: rpcAppendEntries, to: to, prevLogIndex: prevLogIndex, prevLogTerm: prevLogTerm, entries: entries, leaderCommit: node.commitIndex})
+}
+
+func (node *node) send(message rpc) {
+	message.from = node.id
+	message.term = node.term
+	receiver := node.simulator.nodes[message.to]
+	node.simulator.schedule(node.simulator.config.MessageLatency, func() {
+		receiver.whenRunning(func() { receiver.receive(message) })
+	})
+}
+
+// receive reports the message to the DA and handles it after the verdict was applied.
+func (node *node) receive(message rpc) {
+	handle := func() { node.handle(message) }
+	switch message.kind {
+	case rpcVoteRequest:
+		node.report(protocol.MessageType_VOTE_REQUEST_RECEIVED, &protocol.VoteRequestReceived{RequestingNodeId: message.from, ReceivingNodeId: node.id, Term: message.term}, handle)
+	case rpcVoteResponse:
+		node.report(protocol.MessageType_VOTE_RECEIVED, &protocol.VoteReceived{VotingNodeId: message.from, VotedNodeId: node.id, VoteGranted: message.success}, handle)
+	case rpcAppendEntries:
+		if len(message.entries) == 0 {
+			handle()
+			return
+		}
+		lastIndex := message.prevLogIndex + uint64(len(message.entries))
+		node.report(protocol.MessageType_LOG_ENTRY_REPLICATED, &protocol.LogEntryReplicated{LeaderId: message.from, ReceivingNodeId: node.id, LogEntryNumber: int64(lastIndex)}, handle)
+	default:
+		handle()
+	}
+}
+
+func (node *node) handle(message rpc) {
+	if message.term > node.term {
+		node.becomeFollower(message.term)
+	}
+
+	switch message.kind {
+	case rpcVoteRequest:
+		lastLogIndex, lastLogTerm := node.lastLog()
+		upToDate := message.lastLogTerm > lastLogTerm || (message.lastLogTerm == lastLogTerm && message.lastLogIndex >= lastLogIndex)
+		granted := message.term == node.term && (node.votedFor == 0 || node.votedFor == message.from) && upToDate
+		if granted {
+			node.votedFor = message.from
+			node.resetElectionTimer()
+		}
+		node.send(rpc{kind: rpcVoteResponse, to: message.from, success: granted})
+	case rpcVoteResponse:
+		if node.state != stateCandidate || message.term != node.term || !message.success {
+			return
+		}
+		node.votes[message.from] = true
+		if node.hasMajority(len(node.votes)) {
+			node.becomeLeader()
+		}
+	case rpcAppendEntries:
+		node.handleAppend(message)
+	case rpcAppendResponse:
+		node.handleAppendResponse(message)
+	}
+}
+
+func (node *node) handleAppend(message rpc) {
+	if message.term < node.term {
+		node.send(rpc{kind: rpcAppendResponse, to: message.from})
+		return
+	}
+
+	node.state = stateFollower
+	node.leader = message.from
+	node.resetElectionTimer()
+
+	if message.prevLogIndex > uint64(len(node.log)) ||
+		(message.prevLogIndex > 0 && node.log[message.prevLogIndex-1].term != message.prevLogTerm) {
+		node.send(rpc{kind: rpcAppendResponse, to: message.from})
+		return
+	}
+
+	for offset, newEntry := range message.entries {
+		index := message.prevLogIndex + uint64(offset) + 1
+		if index <= uint64(len(node.log)) {
+			if node.log[index-1].term == newEntry.term {
+				continue
+			}
+			node.log = node.log[:index-1]
+		}
+		node.log = append(node.log, newEntry)
+	}
+
+	matchIndex := message.prevLogIndex + uint64(len(message.entries))
+	node.send(rpc{kind: rpcAppendResponse, to: message.from, success: true, matchIndex: matchIndex})
+	if message.leaderCommit > node.commitIndex {
+		node.commitTo(util.Min(message.leaderCommit, matchIndex))
+	}
+}
+
+func (node *node) handleAppendResponse(message rpc) {
+	if node.state != stateLeader || message.term != node.term {
+		return
+	}
+
+	if !message.success {
+		if node.nextIndex[message.from] > 1 {
+			node.nextIndex[message.from]--
+		}
+		node.sendAppend(message.from)
+		return
+	}
+
+	if message.matchIndex > node.matchIndex[message.from] {
+		node.matchIndex[message.from] = message.matchIndex
+	}
+	node.nextIndex[message.from] = node.matchIndex[message.from] + 1
+	node.advanceLeaderCommit()
+}
+
+// advanceLeaderCommit commits the last entry of the current term that is stored on a majority of nodes.
+func (node *node) advanceLeaderCommit() {
+	for index := uint64(len(node.log)); index > node.commitIndex; index-- {
+		if node.log[index-1].term != node.term {
+			return
+		}
+
+		replicas := 1
+		for id, matchIndex := range node.matchIndex {
+			if id != node.id && matchIndex >= index {
+				replicas++
+			}
+		}
+		if node.hasMajority(replicas) {
+			node.commitTo(index)
+			return
+		}
+	}
+}
+
+// commitTo reports every newly committed entry with data to the DA.
+func (node *node) commitTo(index uint64) {
+	for node.commitIndex < index && !node.crashed {
+		node.commitIndex++
+		if len(node.log[node.commitIndex-1].data) == 0 {
+			continue
+		}
+		node.report(protocol.MessageType_LOG_ENTRY_COMMITTED, &protocol.LogEntryCommitted{LeaderId: node.leader, ReceivingNodeId: node.id, LogEntryNumber: int64(node.commitIndex)}, func() {})
+	}
+}
+
+func (node *node) hasMajority(count int) bool {
+	return count > len(node.simulator.nodeIds)/2
+}
+
+func (node *node) lastLog() (uint64, uint64) {
+	if len(node.log) == 0 {
+		return 0, 0
+	}
+	return uint64(len(node.log)), node.log[len(node.log)-1].term
+}
@@ -0,0 +1,313 @@
+// Package simulator runs simulated Raft nodes on a virtual clock and connects them to a real Processor and
+// ActionPicker through protocol messages. Runs are deterministic for a seed, so fault scenarios can be asserted,
+// e.g. that stopping the leader triggers a re-election.
+package simulator
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/FatProteins/master-thesis-code/events"
+	daLogger "github.com/FatProteins/master-thesis-code/logger"
+	"github.com/FatProteins/master-thesis-code/network"
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/process"
+	"github.com/FatProteins/master-thesis-code/setup"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"math/rand"
+	"time"
+)
+
+var logger = daLogger.NewLogger("simulator")
+
+type Config struct {
+	Nodes int
+	// Seed makes runs reproducible.
+	Seed int64
+	// FaultConfig configures the ActionPicker, i.e. the durations of the actions.
+	FaultConfig        setup.FaultConfig
+	MessageLatency     time.Duration
+	HeartbeatInterval  time.Duration
+	MinElectionTimeout time.Duration
+	MaxElectionTimeout time.Duration
+}
+
+// Verdict is an action the DA performed for a report of a simulated node.
+type Verdict struct {
+	At          time.Duration
+	NodeId      uint32
+	MessageType protocol.MessageType
+	ActionType  protocol.ActionType
+	Duration    time.Duration
+}
+
+// Simulator owns the virtual clock. All simulated nodes run on the goroutine calling Step, RunFor or RunUntil.
+type Simulator struct {
+	config    Config
+	rand      *rand.Rand
+	now       time.Duration
+	queue     eventQueue
+	sequence  uint64
+	nodes     map[uint32]*node
+	nodeIds   []uint32
+	faults    map[uint32][]protocol.ActionType
+	verdicts  []Verdict
+	msgChan   chan network.Message
+	performed Verdict
+	cancel    context.CancelFunc
+}
+
+func NewSimulator(config Config) (*Simulator, error) {
+	if config.Nodes <= 0 {
+		return nil, fmt.Errorf("simulation needs at least one node, but has %d", config.Nodes)
+	}
+	if config.MessageLatency == 0 {
+		config.MessageLatency = 5 * time.Millisecond
+	}
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = 50 * time.Millisecond
+	}
+	if config.MinElectionTimeout == 0 {
+		config.MinElectionTimeout = 150 * time.Millisecond
+	}
+	if config.MaxElectionTimeout <= config.MinElectionTimeout {
+		config.MaxElectionTimeout = 2 * config.MinElectionTimeout
+	}
+
+	simulator := &Simulator{
+		config:  config,
+		rand:    rand.New(rand.NewSource(config.Seed)),
+		nodes:   make(map[uint32]*node, config.Nodes),
+		faults:  make(map[uint32][]protocol.ActionType),
+		msgChan: make(chan network.Message),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	simulator.cancel = cancel
+	decider := &simulatedDecider{ActionPicker: setup.NewActionPicker(config.FaultConfig), simulator: simulator}
+	processor := process.NewProcessor(simulator.msgChan, nil, decider, nil, events.NewHub(), nil, nil, nil)
+	processor.RunAsync(ctx)
+
+	for id := uint32(1); id <= uint32(config.Nodes); id++ {
+		simulator.nodeIds = append(simulator.nodeIds, id)
+		simulator.nodes[id] = &node{id: id, simulator: simulator, state: stateFollower}
+	}
+	for _, id := range simulator.nodeIds {
+		simulator.nodes[id].resetElectionTimer()
+	}
+
+	return simulator, nil
+}
+
+// Close stops the processor.
+func (simulator *Simulator) Close() {
+	simulator.cancel()
+}
+
+// Now returns the virtual time since the start of the simulation.
+func (simulator *Simulator) Now() time.Duration {
+	return simulator.now
+}
+
+// Step runs the next event and advances the clock to it. It returns false if there are no events.
+func (simulator *Simulator) Step() bool {
+	if simulator.queue.Len() == 0 {
+		return false
+	}
+
+	event := heap.Pop(&simulator.queue).(*event)
+	simulator.now = event.at
+	event.run()
+	return true
+}
+
+// RunFor runs all events within the given virtual duration.
+func (simulator *Simulator) RunFor(duration time.Duration) {
+	end := simulator.now + duration
+	for simulator.queue.Len() > 0 && simulator.queue[0].at <= end {
+		simulator.Step()
+	}
+	simulator.now = end
+}
+
+// RunUntil runs events until the condition holds or the virtual time limit is reached.
+func (simulator *Simulator) RunUntil(condition func() bool, limit time.Duration) bool {
+	end := simulator.now + limit
+	for !condition() {
+		if simulator.queue.Len() == 0 || simulator.queue[0].at > end {
+			simulator.now = end
+			return false
+		}
+		simulator.Step()
+	}
+	return true
+}
+
+// Leader returns the running leader with the highest term or 0 if there is none.
+func (simulator *Simulator) Leader() uint32 {
+	var leader *node
+	for _, id := range simulator.nodeIds {
+		candidate := simulator.nodes[id]
+		if candidate.state == stateLeader && !candidate.crashed && (leader == nil || candidate.term > leader.term) {
+			leader = candidate
+		}
+	}
+
+	if leader == nil {
+		return 0
+	}
+	return leader.id
+}
+
+// Propose appends data to the log of the leader.
+func (simulator *Simulator) Propose(data []byte) error {
+	leader := simulator.Leader()
+	if leader == 0 {
+		return errors.New("cluster has no leader")
+	}
+
+	simulator.nodes[leader].propose(data)
+	return nil
+}
+
+// InjectFault requests an action for the next report of the node. The ActionPicker picks the action requested
+// by the message, so the DA performs it.
+func (simulator *Simulator) InjectFault(nodeId uint32, actionType protocol.ActionType) {
+	simulator.faults[nodeId] = append(simulator.faults[nodeId], actionType)
+}
+
+// Verdicts returns all actions other than noop the DA performed.
+func (simulator *Simulator) Verdicts() []Verdict {
+	return simulator.verdicts
+}
+
+// NodeState describes a simulated node.
+type NodeState struct {
+	Id          uint32
+	State       string
+	Term        uint64
+	Leader      uint32
+	LogLength   int
+	CommitIndex uint64
+	Crashed     bool
+	Restarts    int
+}
+
+func (simulator *Simulator) Node(id uint32) (NodeState, bool) {
+	node, ok := simulator.nodes[id]
+	if !ok {
+		return NodeState{}, false
+	}
+
+	return NodeState{
+		Id:          node.id,
+		State:       node.state.String(),
+		Term:        node.term,
+		Leader:      node.leader,
+		LogLength:   len(node.log),
+		CommitIndex: node.commitIndex,
+		Crashed:     node.crashed,
+		Restarts:    node.restarts,
+	}, true
+}
+
+// schedule runs the function after the given virtual delay.
+func (simulator *Simulator) schedule(delay time.Duration, run func()) {
+	simulator.sequence++
+	heap.Push(&simulator.queue, &event{at: simulator.now + delay, sequence: simulator.sequence, run: run})
+}
+
+func (simulator *Simulator) randomDuration(min time.Duration, max time.Duration) time.Duration {
+	return min + time.Duration(simulator.rand.Int63n(int64(max-min)))
+}
+
+// report sends a consensus event of the node through the processor and waits for the DA response.
+func (simulator *Simulator) report(node *node, messageType protocol.MessageType, event proto.Message) Verdict {
+	messageObject, err := anypb.New(event)
+	if err != nil {
+		logger.ErrorErr(err, "Failed to wrap %s", messageType.String())
+		return Verdict{}
+	}
+
+	message := &protocol.Message{MessageType: messageType, MessageObject: messageObject}
+	if faults := simulator.faults[node.id]; len(faults) > 0 {
+		message.ActionType = faults[0]
+		simulator.faults[node.id] = faults[1:]
+	}
+
+	responded := make(chan struct{})
+	peer := network.Peer{NodeId: node.id, ProtocolName: "raft", Identified: true}
+	simulator.msgChan <- network.NewMessage(message, peer, func(*protocol.Message) {
+		close(responded)
+	})
+	<-responded
+
+	verdict := simulator.performed
+	verdict.At = simulator.now
+	verdict.NodeId = node.id
+	verdict.MessageType = messageType
+	if verdict.ActionType != protocol.ActionType_NOOP_ACTION_TYPE {
+		simulator.verdicts = append(simulator.verdicts, verdict)
+	}
+	return verdict
+}
+
+// simulatedDecider lets the ActionPicker decide, but the simulator performs the actions on the virtual clock
+// instead of sleeping and running container commands.
+type simulatedDecider struct {
+	*setup.ActionPicker
+	simulator *Simulator
+}
+
+func (decider *simulatedDecider) GetAction(actionType protocol.ActionType) setup.FaultAction {
+	return &simulatedAction{FaultAction: decider.ActionPicker.GetAction(actionType), actionType: actionType, simulator: decider.simulator}
+}
+
+type simulatedAction struct {
+	setup.FaultAction
+	actionType protocol.ActionType
+	simulator  *Simulator
+}
+
+// Perform records the action, the simulator applies it after the response. It is called on the worker goroutine
+// of the processor while the simulator waits for the response.
+func (action *simulatedAction) Perform(_ context.Context, _ uint32, duration time.Duration, _ func()) {
+	action.simulator.performed = Verdict{ActionType: action.actionType, Duration: duration}
+}
+
+type event struct {
+	at       time.Duration
+	sequence uint64
+	run      func()
+}
+
+// eventQueue orders events by time and, for the same time, by scheduling order.
+type eventQueue []*event
+
+func (queue eventQueue) Len() int {
+	return len(queue)
+}
+
+func (queue eventQueue) Less(i, j int) bool {
+	if queue[i].at == queue[j].at {
+		return queue[i].sequence < queue[j].sequence
+	}
+	return queue[i].at < queue[j].at
+}
+
+func (queue eventQueue) Swap(i, j int) {
+	queue[i], queue[j] = queue[j], queue[i]
+}
+
+func (queue *eventQueue) Push(x any) {
+	*queue = append(*queue, x.(*event))
+}
+
+func (queue *eventQueue) Pop() any {
+	old := *queue
+	last := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,96 @@
+package simulator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/FatProteins/master-thesis-code/network/protocol"
+	"github.com/FatProteins/master-thesis-code/setup"
+)
+
+const testSeed = 42
+
+func newTestSimulator(t *testing.T, seed int64) *Simulator {
+	t.Helper()
+	faultConfig := setup.FaultConfig{}
+	faultConfig.Actions.Stop.MaxDuration = 2000
+	simulator, err := NewSimulator(Config{Nodes: 5, Seed: seed, FaultConfig: faultConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(simulator.Close)
+	return simulator
+}
+
+// stopLeader elects a leader, stops it with its next report, e.g. of the committed proposal, and waits for the
+// re-election.
+// It returns the stopped leader and the new one.
+func stopLeader(t *testing.T, simulator *Simulator) (uint32, uint32) {
+	t.Helper()
+	if !simulator.RunUntil(func() bool { return simulator.Leader() != 0 }, 5*time.Second) {
+		t.Fatal("no leader elected")
+	}
+	leader := simulator.Leader()
+
+	simulator.InjectFault(leader, protocol.ActionType_STOP_ACTION_TYPE)
+	err := simulator.Propose([]byte("value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reelected := simulator.RunUntil(func() bool {
+		return simulator.Leader() != 0 && simulator.Leader() != leader
+	}, 5*time.Second)
+	if !reelected {
+		t.Fatalf("no new leader after stopping leader %d", leader)
+	}
+	return leader, simulator.Leader()
+}
+
+func TestStoppingLeaderTriggersReelection(t *testing.T) {
+	simulator := newTestSimulator(t, testSeed)
+	leader, newLeader := stopLeader(t, simulator)
+
+	state, _ := simulator.Node(leader)
+	if !state.Crashed || state.Restarts != 1 {
+		t.Errorf("stopped leader is %+v, want it crashed once", state)
+	}
+	newState, _ := simulator.Node(newLeader)
+	if newState.Term <= state.Term {
+		t.Errorf("new leader has term %d, want it above the term %d of the stopped leader", newState.Term, state.Term)
+	}
+
+	verdicts := simulator.Verdicts()
+	if len(verdicts) != 1 {
+		t.Fatalf("got verdicts %+v, want the stop", verdicts)
+	}
+	stop := verdicts[0]
+	if stop.NodeId != leader || stop.ActionType != protocol.ActionType_STOP_ACTION_TYPE || stop.Duration != 2*time.Second {
+		t.Errorf("got verdict %+v, want a stop of node %d for 2s", stop, leader)
+	}
+
+	// The stopped node restarts and follows the new leader.
+	simulator.RunFor(3 * time.Second)
+	state, _ = simulator.Node(leader)
+	if state.Crashed || state.Leader != simulator.Leader() {
+		t.Errorf("restarted node is %+v, want it to follow leader %d", state, simulator.Leader())
+	}
+}
+
+func TestRunsAreDeterministicForSeed(t *testing.T) {
+	run := func(seed int64) (uint32, uint32, time.Duration, []Verdict) {
+		simulator := newTestSimulator(t, seed)
+		leader, newLeader := stopLeader(t, simulator)
+		return leader, newLeader, simulator.Now(), simulator.Verdicts()
+	}
+
+	leader, newLeader, now, verdicts := run(testSeed)
+	againLeader, againNewLeader, againNow, againVerdicts := run(testSeed)
+	if leader != againLeader || newLeader != againNewLeader || now != againNow {
+		t.Errorf("runs with seed %d differ: leaders %d, %d at %s and %d, %d at %s",
+			testSeed, leader, newLeader, now.String(), againLeader, againNewLeader, againNow.String())
+	}
+	if !reflect.DeepEqual(verdicts, againVerdicts) {
+		t.Errorf("runs with seed %d have different verdicts: %+v and %+v", testSeed, verdicts, againVerdicts)
+	}
+}
@@ -0,0 +1,108 @@
+// Package tracing exports OpenTelemetry spans of the message handling of the DA. Until Setup is called, all spans
+// are no-ops.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	ExporterOtlp = "otlp"
+	ExporterFile = "file"
+)
+
+const serviceName = "distributed-assistant"
+
+// Tracer creates the spans of the DA. It delegates to the provider installed by Setup.
+var Tracer = otel.Tracer("github.com/FatProteins/master-thesis-code")
+
+var enabled atomic.Bool
+
+// noopSpan is returned while tracing is disabled. Starting a span on the no-op tracer still allocates a context.
+var noopSpan = trace.SpanFromContext(context.Background())
+
+// Setup installs a tracer provider exporting to an OTLP collector at the gRPC endpoint, e.g. 'localhost:4317', or
+// to a file with one JSON span per line. The returned function flushes the remaining spans and stops exporting.
+func Setup(ctx context.Context, exporter string, endpoint string, filePath string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var file *os.File
+	var err error
+	switch exporter {
+	case ExporterOtlp:
+		spanExporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpoint(endpoint), otlptracegrpc.WithInsecure())
+	case ExporterFile:
+		file, err = os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, err
+		}
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter '%s'", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	enabled.Store(true)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span, but allocates nothing while tracing is disabled.
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	if !enabled.Load() {
+		return ctx, noopSpan
+	}
+	return Tracer.Start(ctx, name)
+}
+
+// StartAt starts a span that began at the timestamp, e.g. when a message was read.
+func StartAt(ctx context.Context, name string, timestamp time.Time) (context.Context, trace.Span) {
+	if !enabled.Load() {
+		return ctx, noopSpan
+	}
+	return Tracer.Start(ctx, name, trace.WithTimestamp(timestamp))
+}
+
+// Fail marks the span as failed with the error.
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func Node(node uint32) attribute.KeyValue {
+	return attribute.Int64("da.node", int64(node))
+}
+
+func MessageType(messageType string) attribute.KeyValue {
+	return attribute.String("da.message_type", messageType)
+}
+
+func Action(action string) attribute.KeyValue {
+	return attribute.String("da.action", action)
+}
@@ -1,7 +1,7 @@
 package util
 
 type number interface {
-	int | int8 | int16 | int32 | int64 | uint | uint8 | uint16 | uint32 | uint64 | float32 | float64
+	int | int8 | int16 | int32 | ~int64 | uint | uint8 | uint16 | uint32 | uint64 | float32 | float64
 }
 
 func Max[T number](elements ...T) T {
@@ -15,3 +15,15 @@ func Max[T number](elements ...T) T {
 
 	return largest
 }
+
+func Min[T number](first T, elements ...T) T {
+	smallest := first
+
+	for _, value := range elements {
+		if value < smallest {
+			smallest = value
+		}
+	}
+
+	return smallest
+}